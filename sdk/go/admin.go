@@ -0,0 +1,18 @@
+package poubelle
+
+import "fmt"
+
+// ChangePassword runs ALTER USER user PASSWORD 'newPass' and interprets the
+// server's acknowledgment. user must be a plain identifier, and newPass is
+// escaped like a QueryParams argument.
+func (c *Client) ChangePassword(user, newPass string) error {
+	if !isIdentifier(user) {
+		return fmt.Errorf("invalid user name %q", user)
+	}
+	password, err := c.quoteStyle.quote(newPass)
+	if err != nil {
+		return fmt.Errorf("invalid password: %v", err)
+	}
+
+	return c.ExecuteDDL(fmt.Sprintf("ALTER USER %s PASSWORD %s", user, password))
+}
@@ -0,0 +1,56 @@
+package poubelle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExecuteDDL(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "CREATE TABLE users (id INT)" {
+			return "Table users created\n"
+		}
+		return "Error: Storage error: Table users already exists\n"
+	})
+
+	if err := client.ExecuteDDL("CREATE TABLE users (id INT)"); err != nil {
+		t.Errorf("ExecuteDDL() error = %v", err)
+	}
+
+	err := client.ExecuteDDL("CREATE TABLE users (id INT, name TEXT)")
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Message != "Storage error: Table users already exists" {
+		t.Errorf("ExecuteDDL() error = %v, want *ServerError", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if query == "ALTER USER alice PASSWORD 's3cret'" {
+			return "OK\n"
+		}
+		return "Error: User not found\n"
+	})
+
+	if err := client.ChangePassword("alice", "s3cret"); err != nil {
+		t.Errorf("ChangePassword() error = %v", err)
+	}
+
+	var serverErr *ServerError
+	if err := client.ChangePassword("bob", "s3cret"); !errors.As(err, &serverErr) {
+		t.Errorf("ChangePassword(bob) error = %v, want *ServerError", err)
+	}
+
+	before := len(log.all())
+	if err := client.ChangePassword("alice; DROP TABLE users", "x"); err == nil {
+		t.Error("expected invalid user name to be rejected")
+	}
+	if err := client.ChangePassword("alice", "x' OR '1"); err == nil {
+		t.Error("expected password with a quote to be rejected")
+	}
+	if len(log.all()) != before {
+		t.Error("rejected input reached the server")
+	}
+}
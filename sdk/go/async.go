@@ -0,0 +1,56 @@
+package poubelle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExecuteAsync sends sql and returns without waiting for the response. The
+// response is read and discarded at the start of the next operation on the
+// connection, so the protocol stays in step.
+//
+// The trade-off is that a failure of the statement is only seen by that next
+// operation, which then returns it without running its own statement.
+func (c *Client) ExecuteAsync(sql string) error {
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.checkStatement(sql); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ready(); err != nil {
+		return err
+	}
+	c.recordHistory(sql)
+	if err := c.send(ctx, sql); err != nil {
+		return err
+	}
+	c.pending++
+	return nil
+}
+
+// drainPending reads the responses of statements sent with ExecuteAsync. It
+// returns the first error acknowledgment among them.
+func (c *Client) drainPending() error {
+	var firstErr error
+	for c.pending > 0 {
+		c.armReadDeadline()
+		result, err := readUntilPrompt(c.reader, "poubelle> ", c.maxResponseSize)
+		c.clearReadDeadline()
+		if err != nil {
+			c.resetConn()
+			return fmt.Errorf("reading response to async statement: %w", err)
+		}
+		c.pending--
+
+		if err := ackError(strings.TrimSpace(c.extractNotices(result))); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("async statement failed: %w", err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,65 @@
+package poubelle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteAsync(t *testing.T) {
+	table := &textTable{}
+	client := connectMock(t, table.handle)
+
+	for _, name := range []string{"first", "second"} {
+		if err := client.ExecuteAsync("INSERT INTO logs (data) VALUES ('" + name + "')"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := client.Execute("SELECT * FROM logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{{"data": "first"}, {"data": "second"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	if err := client.ExecuteAsync("INSERT INTO logs (data) VALUES ('third')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ExecuteDDL("INSERT INTO logs (data) VALUES ('fourth')"); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := client.Execute("SELECT * FROM logs"); err != nil || len(rows) != 4 {
+		t.Errorf("Execute() = %v, %v, want 4 rows", rows, err)
+	}
+}
+
+func TestExecuteAsyncErrorSurfacesLater(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if query == "INSERT INTO missing (id) VALUES (1)" {
+			return "Error: Table missing not found\n"
+		}
+		return "OK\n"
+	})
+
+	if err := client.ExecuteAsync("INSERT INTO missing (id) VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.Query("SELECT * FROM users")
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("Query() error = %v, want the async statement's *ServerError", err)
+	}
+
+	if result, err := client.Query("SELECT * FROM users"); err != nil || result != "OK" {
+		t.Errorf("Query() = %q, %v, want OK", result, err)
+	}
+	if got := log.all(); len(got) != 2 {
+		t.Errorf("server received %q, want the failing insert and one select", got)
+	}
+}
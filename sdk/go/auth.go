@@ -0,0 +1,73 @@
+package poubelle
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A server that supports challenge-response authentication sends
+//
+//	Challenge: <nonce> <base64 salt> <iterations>
+//
+// in place of the Password prompt. The client answers with a base64 SCRAM
+// style proof on a single line, so the password itself never crosses the
+// wire:
+//
+//	SaltedPassword  = PBKDF2-HMAC-SHA256(password, salt, iterations)
+//	ClientKey       = HMAC(SaltedPassword, "Client Key")
+//	StoredKey       = SHA256(ClientKey)
+//	ClientSignature = HMAC(StoredKey, username + "," + nonce)
+//	ClientProof     = ClientKey XOR ClientSignature
+
+type challenge struct {
+	nonce      string
+	salt       []byte
+	iterations int
+}
+
+func parseChallenge(line string) (challenge, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return challenge{}, fmt.Errorf("malformed authentication challenge %q", line)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return challenge{}, fmt.Errorf("malformed challenge salt: %v", err)
+	}
+	iterations, err := strconv.Atoi(fields[2])
+	if err != nil || iterations <= 0 {
+		return challenge{}, fmt.Errorf("malformed challenge iteration count %q", fields[2])
+	}
+
+	return challenge{nonce: fields[0], salt: salt, iterations: iterations}, nil
+}
+
+func challengeProof(username, password string, ch challenge) (string, error) {
+	salted, err := pbkdf2.Key(sha256.New, password, ch.salt, ch.iterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+
+	clientKey := hmacSHA256(salted, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	signature := hmacSHA256(storedKey[:], []byte(username+","+ch.nonce))
+
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+
+	return base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,165 @@
+package poubelle
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// challengeHandshake verifies a proof the way a server holding only the
+// stored key would.
+func challengeHandshake(password string) func(net.Conn, *bufio.Reader) bool {
+	salt := []byte("pepper")
+	salted, _ := pbkdf2.Key(sha256.New, password, salt, 4096, sha256.Size)
+	storedKey := sha256.Sum256(hmacSHA256(salted, []byte("Client Key")))
+
+	return func(conn net.Conn, reader *bufio.Reader) bool {
+		fmt.Fprint(conn, "Username: ")
+		username, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		username = strings.TrimSpace(username)
+
+		nonce := "c0ffee"
+		fmt.Fprintf(conn, "Challenge: %s %s 4096\n", nonce, base64.StdEncoding.EncodeToString(salt))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		proof, err := base64.StdEncoding.DecodeString(strings.TrimSpace(line))
+		if err != nil || len(proof) != sha256.Size {
+			fmt.Fprint(conn, "Authentication failed\n")
+			return false
+		}
+
+		signature := hmacSHA256(storedKey[:], []byte(username+","+nonce))
+		clientKey := make([]byte, len(proof))
+		for i := range proof {
+			clientKey[i] = proof[i] ^ signature[i]
+		}
+		got := sha256.Sum256(clientKey)
+		if username != "admin" || !hmac.Equal(got[:], storedKey[:]) {
+			fmt.Fprint(conn, "Authentication failed\n")
+			return false
+		}
+
+		fmt.Fprint(conn, "Connected to Poubelle DB\n")
+		return true
+	}
+}
+
+func TestConnectChallengeResponse(t *testing.T) {
+	s := &mockServer{
+		handle:    func(query string) string { return "ok\n" },
+		handshake: challengeHandshake("admin"),
+	}
+	s.start(t)
+
+	var logs bytes.Buffer
+	client, err := NewClient(s.dsn(), WithLogger(log.New(&logs, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() with challenge: %v", err)
+	}
+	defer client.Close()
+
+	if logs.Len() != 0 {
+		t.Errorf("unexpected plaintext warning: %q", logs.String())
+	}
+	if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Errorf("Query() = %q, %v", result, err)
+	}
+}
+
+func TestConnectChallengeWrongPassword(t *testing.T) {
+	s := &mockServer{
+		handle:    func(query string) string { return "ok\n" },
+		handshake: challengeHandshake("admin"),
+	}
+	s.start(t)
+
+	client, err := NewClient(strings.Replace(s.dsn(), "admin:admin", "admin:wrong", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err == nil {
+		t.Error("expected wrong password to fail the challenge")
+	}
+}
+
+func TestConnectPlaintextWarns(t *testing.T) {
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+
+	var logs bytes.Buffer
+	client, err := NewClient(s.dsn(), WithLogger(log.New(&logs, "", 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if !strings.Contains(logs.String(), "plaintext") {
+		t.Errorf("expected plaintext warning, got %q", logs.String())
+	}
+}
+
+func TestConnectHandshakeTimeout(t *testing.T) {
+	hung := make(chan struct{})
+	t.Cleanup(func() { close(hung) })
+	s := &mockServer{
+		handle: func(query string) string { return "ok\n" },
+		handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+			// Accept the connection but never prompt.
+			<-hung
+			return false
+		},
+	}
+	s.start(t)
+
+	client, err := NewClient(s.dsn(), WithHandshakeTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := client.Connect(); !errors.Is(err, ErrHandshakeTimeout) {
+		t.Fatalf("Connect() = %v, want ErrHandshakeTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Connect took %v", elapsed)
+	}
+	if client.connected() {
+		t.Error("half-open connection was kept")
+	}
+}
+
+func TestConnectWithinHandshakeTimeout(t *testing.T) {
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+
+	client, err := NewClient(s.dsn(), WithHandshakeTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Query("SELECT 1"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,155 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BatchError reports the statements of an ExecuteBatch that failed. The
+// other statements of the batch ran and their rows were returned.
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+// BatchFailure is one failed statement of a batch.
+type BatchFailure struct {
+	// Index is the position of the statement in the batch.
+	Index int
+	SQL   string
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	first := e.Failures[0]
+	if len(e.Failures) == 1 {
+		return fmt.Sprintf("batch statement %d failed: %v", first.Index, first.Err)
+	}
+	return fmt.Sprintf("%d batch statements failed, first %d: %v", len(e.Failures), first.Index, first.Err)
+}
+
+// Unwrap returns the error of each failed statement, so errors.As finds a
+// *ServerError from any of them.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// ExecuteBatch sends every statement of sqls before reading any response,
+// so the whole batch costs one round trip, and returns the parsed rows of
+// each in input order. A statement that fails leaves a nil entry and is
+// listed in the returned *BatchError; the rest of the batch still runs.
+// Any other error means the batch did not complete and no results are
+// returned: the connection failed, or the client was closed or its context
+// done. Like every operation, ExecuteBatch first reads the responses of
+// statements sent with ExecuteAsync, and if one of them failed it returns
+// that error without sending the batch.
+//
+// All the statements are written before anything is read, so a batch
+// should be small enough for the server's responses to fit in the socket
+// buffers meanwhile.
+func (c *Client) ExecuteBatch(sqls []string) ([][]Row, error) {
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+	if err := c.setInFlight(c.conn); err != nil {
+		return nil, err
+	}
+
+	stop := c.watchContext(ctx)
+	results, batchErr, err := c.runBatch(sqls)
+	cerr := stop()
+	closed, cancelled := c.finishInFlight()
+	if closed {
+		c.resetConn()
+		return nil, ErrClientClosed
+	}
+	if cerr != nil {
+		c.resetConn()
+		return nil, cerr
+	}
+	if cancelled {
+		c.resetConn()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if batchErr != nil {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+func (c *Client) runBatch(sqls []string) ([][]Row, *BatchError, error) {
+	if err := c.drainPending(); err != nil {
+		return nil, nil, err
+	}
+
+	results := make([][]Row, len(sqls))
+	var batchErr *BatchError
+	fail := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = &BatchError{}
+		}
+		batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: i, SQL: sqls[i], Err: err})
+	}
+
+	rejected := make([]error, len(sqls))
+	for i, sql := range sqls {
+		if err := c.checkStatement(sql); err != nil {
+			rejected[i] = err
+			continue
+		}
+		c.recordHistory(sql)
+		if err := c.writeStatement(sql); err != nil {
+			c.resetConn()
+			if isConnClosed(err) {
+				err = fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+			}
+			return nil, nil, err
+		}
+	}
+	c.markWritten()
+
+	for i := range sqls {
+		if rejected[i] != nil {
+			fail(i, rejected[i])
+			continue
+		}
+		c.armReadDeadline()
+		result, err := readUntilPrompt(c.reader, "poubelle> ", c.maxResponseSize)
+		c.clearReadDeadline()
+		if err != nil {
+			c.resetConn()
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, nil, fmt.Errorf("batch statement %d timed out: %w", i, err)
+			}
+			return nil, nil, fmt.Errorf("reading response to batch statement %d: %w", i, err)
+		}
+
+		result = strings.TrimSpace(c.extractNotices(result))
+		if err := ackError(result); err != nil {
+			fail(i, err)
+			continue
+		}
+		rows, err := c.decodeRows(result)
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		results[i] = rows
+	}
+	return results, batchErr, nil
+}
@@ -0,0 +1,104 @@
+package poubelle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteBatch(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "SELECT * FROM users":
+			return `{"id": Int(1)}` + "\n" + `{"id": Int(2)}` + "\n"
+		case "SELECT * FROM teams":
+			return `{"name": Text("core")}` + "\n"
+		case "SELECT * FROM empty":
+			return "No rows\n"
+		}
+		return "Error: table not found\n"
+	})
+
+	results, err := client.ExecuteBatch([]string{
+		"SELECT * FROM users",
+		"SELECT * FROM missing",
+		"SELECT * FROM teams",
+		" ",
+		"SELECT * FROM empty",
+	})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want *BatchError", err)
+	}
+	if len(batchErr.Failures) != 2 {
+		t.Fatalf("failures = %+v, want 2", batchErr.Failures)
+	}
+	if f := batchErr.Failures[0]; f.Index != 1 || f.SQL != "SELECT * FROM missing" {
+		t.Errorf("first failure = %+v, want index 1", f)
+	}
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Message != "table not found" {
+		t.Errorf("errors.As(*ServerError) = %v", serverErr)
+	}
+	if f := batchErr.Failures[1]; f.Index != 3 || !errors.Is(f.Err, ErrEmptyQuery) {
+		t.Errorf("second failure = %+v, want ErrEmptyQuery at index 3", f)
+	}
+
+	want := [][]Row{
+		{{"id": int64(1)}, {"id": int64(2)}},
+		nil,
+		{{"name": "core"}},
+		nil,
+		{},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+
+	// The connection stays in step after the batch.
+	if got, err := client.Query("SELECT * FROM empty"); err != nil || got != "No rows" {
+		t.Errorf("Query() after batch = %q, %v", got, err)
+	}
+}
+
+func TestExecuteBatchAllSucceed(t *testing.T) {
+	client := connectMock(t, func(query string) string { return `{"q": Text("` + query + `")}` + "\n" })
+
+	results, err := client.ExecuteBatch([]string{"SELECT a", "SELECT b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0][0]["q"] != "SELECT a" || results[1][0]["q"] != "SELECT b" {
+		t.Errorf("results = %v", results)
+	}
+}
+
+func TestExecuteBatchAfterFailedAsync(t *testing.T) {
+	var got []string
+	client := connectMock(t, func(query string) string {
+		got = append(got, query)
+		if query == "INSERT INTO missing VALUES (1)" {
+			return "Error: table not found\n"
+		}
+		return "No rows\n"
+	})
+
+	if err := client.ExecuteAsync("INSERT INTO missing VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.ExecuteBatch([]string{"SELECT * FROM users"})
+	var batchErr *BatchError
+	var serverErr *ServerError
+	if errors.As(err, &batchErr) || !errors.As(err, &serverErr) || results != nil {
+		t.Fatalf("ExecuteBatch() = %v, %v, want the async statement's *ServerError alone", results, err)
+	}
+
+	if _, err := client.ExecuteBatch([]string{"SELECT * FROM users"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"INSERT INTO missing VALUES (1)", "SELECT * FROM users"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("server got %q, want %q", got, want)
+	}
+}
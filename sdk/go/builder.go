@@ -0,0 +1,207 @@
+package poubelle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// poubelleKeywords are the words the Poubelle lexer reads as keywords
+// rather than identifiers.
+var poubelleKeywords = []string{
+	"AS", "CREATE", "DROP", "FORMAT", "FROM", "INSERT", "INT", "INTO",
+	"JSON", "LIMIT", "NULL", "SELECT", "TABLE", "TEXT", "VALUES", "WHERE",
+}
+
+// reservedWords is a set of upper-cased words that identifiers built into
+// statements must be quoted to use.
+type reservedWords map[string]bool
+
+// defaultReservedWords is empty: the shipped server's lexer skips double
+// quotes, so "table" still reads as the keyword TABLE and quoting would
+// only change the output.
+var defaultReservedWords = newReservedWords(nil)
+
+func newReservedWords(words []string) reservedWords {
+	set := make(reservedWords, len(words))
+	for _, w := range words {
+		set[strings.ToUpper(w)] = true
+	}
+	return set
+}
+
+// Keywords returns the keywords of the Poubelle lexer, a starting list for
+// WithReservedWords on a server that accepts double-quoted identifiers.
+func Keywords() []string {
+	return append([]string(nil), poubelleKeywords...)
+}
+
+// WithReservedWords sets the words that the client's BuildWhere, OrderBy
+// and GroupBy, and InsertStruct, BulkInsert, RestoreJSONL, DescribeTable
+// and Dump double-quote when they appear as a column or table name,
+// matched ignoring case. Nothing is quoted by default, since the shipped
+// server does not accept quoted identifiers.
+func WithReservedWords(words []string) Option {
+	return func(c *Client) {
+		c.reservedWords = newReservedWords(words)
+	}
+}
+
+// quoteIdent returns name in a form safe to splice into a statement as a
+// column or table name. Only plain identifiers are accepted. They are
+// written as is, or in double quotes if they are one of the reserved
+// words, so that they are not read as keywords.
+func (r reservedWords) quoteIdent(name string) (string, error) {
+	if !isIdentifier(name) {
+		return "", fmt.Errorf("invalid identifier %q", name)
+	}
+	if r[strings.ToUpper(name)] {
+		return `"` + name + `"`, nil
+	}
+	return name, nil
+}
+
+// quoteIdents is quoteIdent for each of names.
+func (r reservedWords) quoteIdents(names []string) ([]string, error) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		q, err := r.quoteIdent(name)
+		if err != nil {
+			return nil, err
+		}
+		quoted[i] = q
+	}
+	return quoted, nil
+}
+
+// BuildWhere returns a WHERE clause matching every entry of filters, joined
+// with AND in key order so the same filters always give the same clause. A
+// nil value becomes IS NULL and a slice becomes IN (...); other values are
+// escaped as in QueryParams with the default QuoteVerbatim style. Keys must
+// be plain identifiers. An empty map gives an empty clause.
+func BuildWhere(filters map[string]interface{}) (string, error) {
+	return buildWhere(filters, QuoteVerbatim, defaultReservedWords)
+}
+
+// BuildWhere is like the package-level BuildWhere but quotes text with the
+// client's quote style and identifiers with its reserved words.
+func (c *Client) BuildWhere(filters map[string]interface{}) (string, error) {
+	return buildWhere(filters, c.quoteStyle, c.reservedWords)
+}
+
+func buildWhere(filters map[string]interface{}, style QuoteStyle, reserved reservedWords) (string, error) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conds := make([]string, 0, len(keys))
+	for _, key := range keys {
+		col, err := reserved.quoteIdent(key)
+		if err != nil {
+			return "", err
+		}
+		cond, err := whereCondition(col, filters[key], style)
+		if err != nil {
+			return "", fmt.Errorf("filter %s: %w", key, err)
+		}
+		conds = append(conds, cond)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), nil
+}
+
+func whereCondition(col string, value interface{}, style QuoteStyle) (string, error) {
+	if value == nil {
+		return col + " IS NULL", nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		if rv.Len() == 0 {
+			return "", fmt.Errorf("empty list")
+		}
+		lits := make([]string, rv.Len())
+		for i := range lits {
+			lit, err := formatLiteral(rv.Index(i).Interface(), style)
+			if err != nil {
+				return "", err
+			}
+			lits[i] = lit
+		}
+		return col + " IN (" + strings.Join(lits, ", ") + ")", nil
+	}
+
+	lit, err := formatLiteral(value, style)
+	if err != nil {
+		return "", err
+	}
+	return col + " = " + lit, nil
+}
+
+// OrderBy returns an ORDER BY clause for cols, each a plain identifier
+// optionally followed by ASC or DESC, as in OrderBy("name", "id DESC"). It
+// gives an empty clause for no columns and an error for anything else, so
+// a sort column taken from user input cannot inject SQL.
+func OrderBy(cols ...string) (string, error) {
+	return orderBy(cols, defaultReservedWords)
+}
+
+// OrderBy is like the package-level OrderBy but quotes columns with the
+// client's reserved words.
+func (c *Client) OrderBy(cols ...string) (string, error) {
+	return orderBy(cols, c.reservedWords)
+}
+
+func orderBy(cols []string, reserved reservedWords) (string, error) {
+	if len(cols) == 0 {
+		return "", nil
+	}
+
+	terms := make([]string, len(cols))
+	for i, col := range cols {
+		name, dir, hasDir := strings.Cut(strings.TrimSpace(col), " ")
+		name, err := reserved.quoteIdent(name)
+		if err != nil {
+			return "", err
+		}
+		if hasDir {
+			dir = strings.ToUpper(strings.TrimSpace(dir))
+			if dir != "ASC" && dir != "DESC" {
+				return "", fmt.Errorf("invalid sort direction in %q, want ASC or DESC", col)
+			}
+			name += " " + dir
+		}
+		terms[i] = name
+	}
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// GroupBy returns a GROUP BY clause for cols, which must be plain
+// identifiers. It gives an empty clause for no columns.
+func GroupBy(cols ...string) (string, error) {
+	return groupBy(cols, defaultReservedWords)
+}
+
+// GroupBy is like the package-level GroupBy but quotes columns with the
+// client's reserved words.
+func (c *Client) GroupBy(cols ...string) (string, error) {
+	return groupBy(cols, c.reservedWords)
+}
+
+func groupBy(cols []string, reserved reservedWords) (string, error) {
+	if len(cols) == 0 {
+		return "", nil
+	}
+
+	names, err := reserved.quoteIdents(cols)
+	if err != nil {
+		return "", err
+	}
+	return "GROUP BY " + strings.Join(names, ", "), nil
+}
@@ -0,0 +1,96 @@
+package poubelle
+
+import "testing"
+
+func TestBuildWhere(t *testing.T) {
+	clause, err := BuildWhere(map[string]interface{}{
+		"name":       "Alice",
+		"id":         7,
+		"deleted_at": nil,
+		"role":       []string{"admin", "owner"},
+		"team":       []interface{}{1, nil},
+		"token":      []byte("abc"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WHERE deleted_at IS NULL AND id = 7 AND name = 'Alice' AND role IN ('admin', 'owner') AND team IN (1, NULL) AND token = 'abc'"
+	if clause != want {
+		t.Errorf("clause = %q\nwant     %q", clause, want)
+	}
+
+	if clause, err := BuildWhere(nil); err != nil || clause != "" {
+		t.Errorf("BuildWhere(nil) = %q, %v", clause, err)
+	}
+}
+
+func TestBuildWhereRejects(t *testing.T) {
+	for name, filters := range map[string]map[string]interface{}{
+		"injected key":   {"id = 1 OR 1": 1},
+		"comment in key": {"id--": 1},
+		"quoted key":     {`"id"`: 1},
+		"quote in value": {"name": "O'Brien"},
+		"empty list":     {"id": []int{}},
+		"bad element":    {"id": []float32{1.5}},
+	} {
+		if clause, err := BuildWhere(filters); err == nil {
+			t.Errorf("%s: BuildWhere() = %q, want error", name, clause)
+		}
+	}
+}
+
+func TestOrderByGroupBy(t *testing.T) {
+	if got, err := OrderBy("name", "id DESC", "created_at asc"); err != nil || got != "ORDER BY name, id DESC, created_at ASC" {
+		t.Errorf("OrderBy() = %q, %v", got, err)
+	}
+	if got, err := GroupBy("team", "role"); err != nil || got != "GROUP BY team, role" {
+		t.Errorf("GroupBy() = %q, %v", got, err)
+	}
+	if got, err := OrderBy(); err != nil || got != "" {
+		t.Errorf("OrderBy() with no columns = %q, %v", got, err)
+	}
+
+	for _, col := range []string{
+		"name; DROP TABLE users",
+		"name DESC; DROP TABLE users",
+		"(SELECT password FROM users)",
+		"id DESC LIMIT 1",
+		"name -- ",
+		"",
+	} {
+		if got, err := OrderBy("id", col); err == nil {
+			t.Errorf("OrderBy(%q) = %q, want error", col, got)
+		}
+		if got, err := GroupBy(col); err == nil {
+			t.Errorf("GroupBy(%q) = %q, want error", col, got)
+		}
+	}
+}
+
+func TestReservedWords(t *testing.T) {
+	if clause, err := BuildWhere(map[string]interface{}{"format": "json", "id": 1}); err != nil || clause != `WHERE format = 'json' AND id = 1` {
+		t.Errorf("BuildWhere = %q, %v, want nothing quoted by default", clause, err)
+	}
+	if clause, err := newClient(connConfig{}, nil).OrderBy("Limit desc", "id"); err != nil || clause != `ORDER BY Limit DESC, id` {
+		t.Errorf("client OrderBy = %q, %v, want nothing quoted by default", clause, err)
+	}
+
+	client := newClient(connConfig{}, []Option{WithReservedWords(append(Keywords(), "order", "USER"))})
+	if clause, err := client.BuildWhere(map[string]interface{}{"user": "al"}); err != nil || clause != `WHERE "user" = 'al'` {
+		t.Errorf("client BuildWhere = %q, %v", clause, err)
+	}
+	if clause, err := client.OrderBy("Order ASC", "text"); err != nil || clause != `ORDER BY "Order" ASC, "text"` {
+		t.Errorf("client OrderBy = %q, %v", clause, err)
+	}
+	if clause, err := client.GroupBy("user", "name"); err != nil || clause != `GROUP BY "user", name` {
+		t.Errorf("client GroupBy = %q, %v", clause, err)
+	}
+
+	none := newClient(connConfig{}, []Option{WithReservedWords(nil)})
+	if clause, err := none.OrderBy("format"); err != nil || clause != "ORDER BY format" {
+		t.Errorf("OrderBy with no reserved words = %q, %v", clause, err)
+	}
+	if _, err := client.OrderBy(`"order"`); err == nil {
+		t.Error("OrderBy accepted an already quoted name")
+	}
+}
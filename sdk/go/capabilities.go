@@ -0,0 +1,172 @@
+package poubelle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupported is returned by a method whose feature the server is known
+// not to support, from its banner or an earlier Capabilities call.
+var ErrUnsupported = errors.New("not supported by the server")
+
+// Capabilities describes the optional features of the server on the
+// current connection.
+type Capabilities struct {
+	JSONFormat         bool
+	Transactions       bool
+	PreparedStatements bool
+	Compression        bool
+	Cursors            bool
+	// Comments reports whether a statement may start with a /* */
+	// comment, as QueryTagged sends.
+	Comments bool
+	// Advertised reports whether the server listed its features in its
+	// banner. Otherwise they were found by probing, and Compression only
+	// reports whether it was negotiated, since it cannot be probed
+	// without committing to it.
+	Advertised bool
+}
+
+// The feature names a server lists in its banner, as in
+// "Connected to Poubelle DB\nFeatures: json, transactions, cursors".
+const (
+	featureJSON         = "json"
+	featureTransactions = "transactions"
+	featurePrepared     = "prepared"
+	featureCompression  = "compression"
+	featureCursors      = "cursors"
+	featureComments     = "comments"
+)
+
+var featuresRe = regexp.MustCompile(`(?im)^\s*features:[ \t]*(.*)$`)
+
+// parseFeatures returns the set of features listed in banner, or nil if it
+// lists none.
+func parseFeatures(banner string) map[string]bool {
+	m := featuresRe.FindStringSubmatch(banner)
+	if m == nil {
+		return nil
+	}
+	features := make(map[string]bool)
+	for _, f := range strings.Split(m[1], ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			features[f] = true
+		}
+	}
+	return features
+}
+
+// Capabilities reports which optional features the server supports. If
+// the server listed them in its banner that list is used; otherwise each
+// is probed with a harmless statement, such as BEGIN followed by ROLLBACK.
+// The result is cached until the client reconnects.
+func (c *Client) Capabilities() (Capabilities, error) {
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return Capabilities{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ready(); err != nil {
+		return Capabilities{}, err
+	}
+	if c.capabilities != nil {
+		return *c.capabilities, nil
+	}
+
+	var caps Capabilities
+	if c.features != nil {
+		caps = Capabilities{
+			JSONFormat:         c.features[featureJSON],
+			Transactions:       c.features[featureTransactions],
+			PreparedStatements: c.features[featurePrepared],
+			Compression:        c.features[featureCompression] || c.compressed,
+			Cursors:            c.features[featureCursors],
+			Comments:           c.features[featureComments],
+			Advertised:         true,
+		}
+	} else {
+		var err error
+		if caps, err = c.probeCapabilities(ctx); err != nil {
+			return Capabilities{}, err
+		}
+	}
+	c.capabilities = &caps
+	return caps, nil
+}
+
+func (c *Client) probeCapabilities(ctx context.Context) (Capabilities, error) {
+	caps := Capabilities{Compression: c.compressed}
+
+	result, err := c.query(ctx, "SELECT * FROM __tables__ FORMAT JSON")
+	if err != nil {
+		return Capabilities{}, err
+	}
+	caps.JSONFormat = ackError(result) == nil && json.Valid([]byte(result))
+
+	probes := []struct {
+		supported  *bool
+		stmt, undo string
+	}{
+		{&caps.Transactions, "BEGIN", "ROLLBACK"},
+		{&caps.PreparedStatements, "PREPARE poubelle_probe AS SELECT * FROM __tables__", "DEALLOCATE poubelle_probe"},
+		{&caps.Cursors, "DECLARE poubelle_probe CURSOR FOR SELECT * FROM __tables__", "CLOSE poubelle_probe"},
+		{&caps.Comments, "/* poubelle_probe */ SELECT * FROM __tables__", ""},
+	}
+	for _, p := range probes {
+		result, err := c.query(ctx, p.stmt)
+		if err != nil {
+			return Capabilities{}, err
+		}
+		if ackError(result) != nil {
+			continue
+		}
+		*p.supported = true
+		if p.undo == "" {
+			continue
+		}
+		if err := c.exec(ctx, p.undo); err != nil {
+			return Capabilities{}, fmt.Errorf("undoing capability probe %q: %w", p.stmt, err)
+		}
+	}
+	return caps, nil
+}
+
+// requireFeature fails with ErrUnsupported if the server is known not to
+// support feature, with c.mu held. It never probes: a feature is only
+// known to be missing once the banner or a Capabilities call said so.
+func (c *Client) requireFeature(feature string) error {
+	var supported bool
+	switch caps := c.capabilities; {
+	case caps != nil:
+		supported = map[string]bool{
+			featureJSON:         caps.JSONFormat,
+			featureTransactions: caps.Transactions,
+			featurePrepared:     caps.PreparedStatements,
+			featureCompression:  caps.Compression,
+			featureCursors:      caps.Cursors,
+			featureComments:     caps.Comments,
+		}[feature]
+	case c.features != nil:
+		supported = c.features[feature]
+	default:
+		return nil
+	}
+	if !supported {
+		return fmt.Errorf("%w: %s", ErrUnsupported, feature)
+	}
+	return nil
+}
+
+// checkFeature is requireFeature for callers not holding c.mu.
+func (c *Client) checkFeature(feature string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requireFeature(feature)
+}
@@ -0,0 +1,182 @@
+package poubelle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// featureServer starts a mock server whose banner lists features, or none
+// if features is empty.
+func featureServer(t *testing.T, features string, handle func(string) string) *Client {
+	t.Helper()
+
+	s := &mockServer{handle: handle, handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+		fmt.Fprint(conn, "Username: ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		fmt.Fprint(conn, "Password: ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		fmt.Fprint(conn, "Connected to Poubelle DB\n")
+		if features != "" {
+			fmt.Fprintf(conn, "Features: %s\n", features)
+		}
+		return true
+	}}
+	s.start(t)
+
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCapabilitiesAdvertised(t *testing.T) {
+	var log queryLog
+	client := featureServer(t, "JSON, cursors", func(query string) string {
+		log.record(query)
+		return "OK\n"
+	})
+
+	caps, err := client.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Capabilities{JSONFormat: true, Cursors: true, Advertised: true}
+	if caps != want {
+		t.Errorf("capabilities = %+v, want %+v", caps, want)
+	}
+	if got := log.all(); len(got) != 0 {
+		t.Errorf("advertised capabilities were probed: %q", got)
+	}
+
+	if _, err := client.Begin(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Begin() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := client.Cursor("SELECT * FROM t", 10); err != nil {
+		t.Errorf("Cursor() on a server with cursors: %v", err)
+	}
+	if got := log.all(); len(got) != 1 || !strings.HasPrefix(got[0], "DECLARE ") {
+		t.Errorf("queries = %q, want only the DECLARE", got)
+	}
+}
+
+func TestCapabilitiesUnsupportedBeforeProbe(t *testing.T) {
+	client := featureServer(t, "transactions", func(query string) string { return "OK\n" })
+
+	if _, err := client.ExecuteJSON("SELECT * FROM t"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ExecuteJSON() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := client.Cursor("SELECT * FROM t", 10); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Cursor() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := client.QueryTagged("SELECT * FROM t", map[string]string{"app": "x"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("QueryTagged() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := client.QueryTagged("SELECT * FROM t", nil); err != nil {
+		t.Errorf("QueryTagged() without tags sends no comment: %v", err)
+	}
+	tx, err := client.Begin()
+	if err != nil {
+		t.Fatalf("Begin() on a server with transactions: %v", err)
+	}
+	tx.Rollback()
+}
+
+func TestCapabilitiesProbed(t *testing.T) {
+	tests := []struct {
+		name     string
+		rejected []string
+		want     Capabilities
+	}{
+		{"everything", nil, Capabilities{JSONFormat: true, Transactions: true, PreparedStatements: true, Cursors: true, Comments: true}},
+		{"no prepare or cursors", []string{"PREPARE", "DECLARE"}, Capabilities{JSONFormat: true, Transactions: true, Comments: true}},
+		{"plain", []string{"FORMAT JSON", "BEGIN", "PREPARE", "DECLARE", "/*"}, Capabilities{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log queryLog
+			client := featureServer(t, "", func(query string) string {
+				log.record(query)
+				for _, r := range tt.rejected {
+					if strings.Contains(query, r) {
+						return "Error: unsupported statement\n"
+					}
+				}
+				if strings.HasSuffix(query, "FORMAT JSON") {
+					return "[]\n"
+				}
+				return "OK\n"
+			})
+
+			caps, err := client.Capabilities()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if caps != tt.want {
+				t.Errorf("capabilities = %+v, want %+v", caps, tt.want)
+			}
+
+			probes := len(log.all())
+			if again, err := client.Capabilities(); err != nil || again != caps || len(log.all()) != probes {
+				t.Errorf("second Capabilities() = %+v, %v after %d queries, want it cached", again, err, len(log.all())-probes)
+			}
+			undo := map[string]bool{}
+			for _, q := range log.all() {
+				undo[q] = true
+			}
+			if tt.want.Transactions && !undo["ROLLBACK"] {
+				t.Error("transaction probe was not rolled back")
+			}
+			if tt.want.Cursors && !undo["CLOSE poubelle_probe"] {
+				t.Error("cursor probe was not closed")
+			}
+			if !tt.want.PreparedStatements && undo["DEALLOCATE poubelle_probe"] {
+				t.Error("rejected PREPARE probe was deallocated")
+			}
+
+			if !tt.want.Transactions {
+				if _, err := client.Begin(); !errors.Is(err, ErrUnsupported) {
+					t.Errorf("Begin() error = %v, want ErrUnsupported", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCapabilitiesResetOnReconnect(t *testing.T) {
+	var log queryLog
+	client := featureServer(t, "", func(query string) string {
+		log.record(query)
+		return "OK\n"
+	})
+
+	if _, err := client.Capabilities(); err != nil {
+		t.Fatal(err)
+	}
+	probes := len(log.all())
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Capabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.all()) != 2*probes {
+		t.Errorf("capabilities were not probed again after reconnecting")
+	}
+
+	if _, err := (&Client{session: &session{}}).Capabilities(); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Capabilities() before Connect error = %v, want ErrNotConnected", err)
+	}
+}
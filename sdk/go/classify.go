@@ -0,0 +1,162 @@
+package poubelle
+
+import (
+	"iter"
+	"strings"
+)
+
+// StatementType is the broad kind of a SQL statement, as reported by
+// ClassifyStatement.
+type StatementType int
+
+const (
+	// Other covers statements that are neither reads, writes nor DDL, such
+	// as BEGIN or SET, and anything unrecognized.
+	Other StatementType = iota
+	Read
+	Write
+	DDL
+)
+
+func (t StatementType) String() string {
+	switch t {
+	case Read:
+		return "Read"
+	case Write:
+		return "Write"
+	case DDL:
+		return "DDL"
+	default:
+		return "Other"
+	}
+}
+
+var statementTypes = map[string]StatementType{
+	"SELECT":   Read,
+	"SHOW":     Read,
+	"DESCRIBE": Read,
+	"DESC":     Read,
+	"EXPLAIN":  Read,
+	"INSERT":   Write,
+	"UPDATE":   Write,
+	"DELETE":   Write,
+	"MERGE":    Write,
+	"UPSERT":   Write,
+	"REPLACE":  Write,
+	"CREATE":   DDL,
+	"DROP":     DDL,
+	"ALTER":    DDL,
+	"TRUNCATE": DDL,
+	"RENAME":   DDL,
+}
+
+// ClassifyStatement reports whether sql reads, writes or changes the schema,
+// judging by its leading keyword after any comments and whitespace. For a
+// WITH statement the keyword after the common table expressions decides,
+// except that a CTE which itself modifies data makes the statement a write.
+func ClassifyStatement(sql string) StatementType {
+	next, stop := iter.Pull2(sqlWords(sql))
+	defer stop()
+
+	word, _, ok := next()
+	if !ok {
+		return Other
+	}
+	if word != "WITH" {
+		return statementTypes[word]
+	}
+
+	writes := false
+	for word, depth, ok := next(); ok; word, depth, ok = next() {
+		t, known := statementTypes[word]
+		if !known || t == DDL {
+			continue
+		}
+		if depth > 0 {
+			writes = writes || t == Write
+			continue
+		}
+		if writes {
+			return Write
+		}
+		return t
+	}
+	return Other
+}
+
+// sqlWords yields the uppercased keywords and identifiers of sql with their
+// parenthesis depth, skipping comments, string literals and quoted
+// identifiers.
+func sqlWords(sql string) iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {
+		depth := 0
+		for i := 0; i < len(sql); {
+			ch := sql[i]
+			switch {
+			case strings.HasPrefix(sql[i:], "--"):
+				end := strings.IndexByte(sql[i:], '\n')
+				if end < 0 {
+					return
+				}
+				i += end + 1
+			case strings.HasPrefix(sql[i:], "/*"):
+				end := strings.Index(sql[i+2:], "*/")
+				if end < 0 {
+					return
+				}
+				i += end + 4
+			case ch == '\'' || ch == '"':
+				end := strings.IndexByte(sql[i+1:], ch)
+				if end < 0 {
+					return
+				}
+				i += end + 2
+			case ch == '(':
+				depth++
+				i++
+			case ch == ')':
+				depth--
+				i++
+			case isWordByte(ch):
+				start := i
+				for i < len(sql) && isWordByte(sql[i]) {
+					i++
+				}
+				if !yield(strings.ToUpper(sql[start:i]), depth) {
+					return
+				}
+			default:
+				i++
+			}
+		}
+	}
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+// isEmptyStatement reports whether sql holds nothing but whitespace and
+// comments.
+func isEmptyStatement(sql string) bool {
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\n' || sql[i] == '\r':
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return true
+			}
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return true
+			}
+			i += end + 3
+		default:
+			return false
+		}
+	}
+	return true
+}
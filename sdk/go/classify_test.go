@@ -0,0 +1,69 @@
+package poubelle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want StatementType
+	}{
+		{"SELECT * FROM users", Read},
+		{"  select id from users", Read},
+		{"SHOW TABLES", Read},
+		{"INSERT INTO users (id) VALUES (1)", Write},
+		{"update users set name = 'x'", Write},
+		{"DELETE FROM users", Write},
+		{"CREATE TABLE users (id INT)", DDL},
+		{"DROP TABLE users", DDL},
+		{"BEGIN", Other},
+		{"", Other},
+		{"   ", Other},
+		{"-- just a comment", Other},
+
+		{"/* app='billing' */ SELECT * FROM users", Read},
+		{"-- fetch users\nSELECT * FROM users", Read},
+		{"/* INSERT */ -- DELETE\n /* nested */ SELECT 1", Read},
+		{"/* unterminated SELECT", Other},
+
+		{"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent", Read},
+		{"WITH a AS (SELECT 1), b AS (SELECT 2) SELECT * FROM a, b", Read},
+		{"WITH RECURSIVE t(n) AS (SELECT 1) SELECT n FROM t", Read},
+		{"with src as (select * from staging) insert into users select * from src", Write},
+		{"WITH gone AS (DELETE FROM users RETURNING id) SELECT * FROM gone", Write},
+		{"WITH x AS (SELECT 'INSERT' AS \"UPDATE\") SELECT * FROM x", Read},
+		{"WITH x AS (SELECT 1)", Other},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyStatement(tt.sql); got != tt.want {
+			t.Errorf("ClassifyStatement(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestEmptyQuery(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "ok\n"
+	})
+
+	for _, sql := range []string{"", "   ", "\t\r\n", "-- just a comment", "/* note */ \n -- more"} {
+		if _, err := client.Query(sql); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("Query(%q) err = %v, want ErrEmptyQuery", sql, err)
+		}
+		if err := client.ExecuteAsync(sql); !errors.Is(err, ErrEmptyQuery) {
+			t.Errorf("ExecuteAsync(%q) err = %v, want ErrEmptyQuery", sql, err)
+		}
+	}
+	if got := log.all(); len(got) != 0 {
+		t.Errorf("server received %q", got)
+	}
+
+	if got, err := client.Query("/* tagged */ SELECT 1"); err != nil || got != "ok" {
+		t.Errorf("Query() = %q, %v", got, err)
+	}
+}
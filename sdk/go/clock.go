@@ -0,0 +1,45 @@
+package poubelle
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for the client's timing: slow-query
+// measurement and the waits between retries. Connection deadlines always
+// use the real clock, because the network stack enforces them.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock makes the client read time from clock instead of the system
+// clock. It is meant for tests that need time-dependent behavior to be
+// deterministic. A nil clock restores the system clock.
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		if clock == nil {
+			clock = realClock{}
+		}
+		c.clock = clock
+	}
+}
+
+// sleep waits for d on the client's clock or until ctx is done, returning
+// ctx's error in the latter case.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-c.clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package poubelle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to. After advances it by
+// the wait and fires at once, so retry backoff costs no real time.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	waited []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// After records d and advances the clock by it.
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waited = append(f.waited, d)
+	f.now = f.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+	return ch
+}
+
+func (f *fakeClock) Waits() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.waited...)
+}
+
+func TestConnectRetryBackoffOnFakeClock(t *testing.T) {
+	s, attempts := droppingServer(t, 3)
+	clock := newFakeClock()
+	policy := ExponentialBackoff{Initial: time.Hour, Max: 3 * time.Hour, MaxAttempts: 5}
+	client, err := NewClient(s.dsn(), WithRetryPolicy(policy), WithBackoffJitter(0), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if n := attempts.Load(); n != 4 {
+		t.Errorf("server saw %d connections, want 4", n)
+	}
+	want := []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}
+	if got := clock.Waits(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("waits = %v, want %v", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Connect took %v of real time", elapsed)
+	}
+}
+
+func TestSleepHonoursContext(t *testing.T) {
+	client := newClient(connConfig{}, []Option{WithClock(blockedClock{})})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("sleep() = %v, want context.Canceled", err)
+	}
+}
+
+// blockedClock never fires.
+type blockedClock struct{}
+
+func (blockedClock) Now() time.Time                         { return time.Time{} }
+func (blockedClock) After(d time.Duration) <-chan time.Time { return nil }
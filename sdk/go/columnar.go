@@ -0,0 +1,123 @@
+package poubelle
+
+import "sort"
+
+// ColumnBatch is a query result grouped by column. Columns are sorted by
+// name, since the server prints each row's columns in no fixed order.
+// Values[i] holds the values of Columns[i], one per row, with nil for NULL
+// or a column the row did not include.
+type ColumnBatch struct {
+	Columns []string
+	// Kinds holds the kind of the first non-null value of each column, or
+	// KindNull if every value is null.
+	Kinds  []ValueKind
+	Values [][]interface{}
+	Len    int
+}
+
+// Column returns the values of the named column, or nil if there is no such
+// column.
+func (b *ColumnBatch) Column(name string) []interface{} {
+	for i, col := range b.Columns {
+		if col == name {
+			return b.Values[i]
+		}
+	}
+	return nil
+}
+
+// ExecuteColumnar runs sql like Execute but returns the values grouped by
+// column.
+func (c *Client) ExecuteColumnar(sql string, args ...interface{}) (*ColumnBatch, error) {
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.decodeColumnar(result)
+}
+
+// decodeColumnar groups the rows of result by column, handling duplicate
+// column names and truncated records as decodeRows does.
+func (c *Client) decodeColumnar(result string) (*ColumnBatch, error) {
+	batch := &ColumnBatch{}
+	index := make(map[string]int)
+
+	for line := range recordLines(result) {
+		if isTruncatedRecord(line) {
+			if err := c.truncatedRecord(line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !isRecord(line) {
+			continue
+		}
+
+		fields := make(map[string]TypedValue)
+		var keys []string
+		dup := ""
+		for key, raw := range rowFields(line) {
+			if _, seen := fields[key]; seen {
+				if dup == "" {
+					dup = key
+				}
+			} else {
+				keys = append(keys, key)
+			}
+			fields[key] = parseTypedValue(raw)
+		}
+		if dup != "" {
+			if err := c.duplicateColumn(dup, line); err != nil {
+				return nil, err
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		for _, key := range keys {
+			i, ok := index[key]
+			if !ok {
+				i = len(batch.Columns)
+				index[key] = i
+				batch.Columns = append(batch.Columns, key)
+				batch.Kinds = append(batch.Kinds, KindNull)
+				batch.Values = append(batch.Values, make([]interface{}, batch.Len, batch.Len+1))
+			}
+
+			v := fields[key]
+			if batch.Kinds[i] == KindNull {
+				batch.Kinds[i] = v.Kind
+			}
+			batch.Values[i] = append(batch.Values[i], v.Interface())
+		}
+
+		batch.Len++
+		for i := range batch.Values {
+			if len(batch.Values[i]) < batch.Len {
+				batch.Values[i] = append(batch.Values[i], nil)
+			}
+		}
+	}
+
+	batch.sortColumns()
+	return batch, nil
+}
+
+// sortColumns orders the columns of b by name.
+func (b *ColumnBatch) sortColumns() {
+	order := make([]int, len(b.Columns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return b.Columns[order[i]] < b.Columns[order[j]] })
+
+	columns := make([]string, len(order))
+	kinds := make([]ValueKind, len(order))
+	values := make([][]interface{}, len(order))
+	for i, o := range order {
+		columns[i], kinds[i], values[i] = b.Columns[o], b.Kinds[o], b.Values[o]
+	}
+	b.Columns, b.Kinds, b.Values = columns, kinds, values
+}
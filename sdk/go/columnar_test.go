@@ -0,0 +1,123 @@
+package poubelle
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecuteColumnar(t *testing.T) {
+	response := `{"id": Int(1), "name": Text("Alice"), "age": Null}
+{"id": Int(2), "name": Text("Bob"), "age": Int(30)}
+{"id": Int(3), "name": Null, "age": Int(41), "tags": List([Text("x")])}
+`
+	client := connectMock(t, func(query string) string { return response })
+
+	batch, err := client.ExecuteColumnar("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := client.Execute("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"age", "id", "name", "tags"}; !reflect.DeepEqual(batch.Columns, want) {
+		t.Errorf("Columns = %v, want %v", batch.Columns, want)
+	}
+	if want := []ValueKind{KindInt, KindInt, KindText, KindList}; !reflect.DeepEqual(batch.Kinds, want) {
+		t.Errorf("Kinds = %v, want %v", batch.Kinds, want)
+	}
+	if batch.Len != len(rows) {
+		t.Fatalf("Len = %d, want %d", batch.Len, len(rows))
+	}
+
+	for i, col := range batch.Columns {
+		values := batch.Column(col)
+		if len(values) != batch.Len {
+			t.Fatalf("column %s has %d values, want %d", col, len(values), batch.Len)
+		}
+		for r, row := range rows {
+			if !reflect.DeepEqual(values[r], row[col]) {
+				t.Errorf("column %s row %d = %#v, want %#v", col, r, values[r], row[col])
+			}
+		}
+		if !reflect.DeepEqual(batch.Values[i], values) {
+			t.Errorf("Column(%q) does not match Values[%d]", col, i)
+		}
+	}
+
+	if batch.Column("missing") != nil {
+		t.Error("Column(missing) should be nil")
+	}
+}
+
+func TestExecuteColumnarNoRows(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "No rows\n" })
+
+	batch, err := client.ExecuteColumnar("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Len != 0 || len(batch.Columns) != 0 {
+		t.Errorf("batch = %+v, want empty", batch)
+	}
+}
+
+func TestExecuteColumnarDuplicateColumns(t *testing.T) {
+	handle := func(query string) string {
+		return "{\"id\": Int(1), \"id\": Int(2)}\n"
+	}
+
+	var logs strings.Builder
+	lenient := connectMock(t, handle, WithLogger(log.New(&logs, "", 0)))
+	batch, err := lenient.ExecuteColumnar("SELECT a.id, b.id FROM a, b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int64(2)}; !reflect.DeepEqual(batch.Column("id"), want) {
+		t.Errorf("id = %v, want %v, the last value", batch.Column("id"), want)
+	}
+	if !strings.Contains(logs.String(), `duplicate column "id"`) {
+		t.Errorf("no warning logged: %q", logs.String())
+	}
+
+	strict := connectMock(t, handle, WithStrictParsing(true))
+	var parseErr *ParseError
+	if _, err := strict.ExecuteColumnar("SELECT a.id, b.id FROM a, b"); !errors.As(err, &parseErr) {
+		t.Errorf("ExecuteColumnar() err = %v, want *ParseError", err)
+	}
+	if _, err := strict.ExecuteRows("SELECT a.id, b.id FROM a, b"); !errors.As(err, &parseErr) {
+		t.Errorf("ExecuteRows() err = %v, want *ParseError", err)
+	}
+}
+
+func TestExecuteColumnarTruncatedRecords(t *testing.T) {
+	handle := func(query string) string {
+		return "{\"id\": Int(1)}\n{\"id\": Int(2), \"name\": Text(\"Bo\n"
+	}
+
+	var logs strings.Builder
+	lenient := connectMock(t, handle, WithLogger(log.New(&logs, "", 0)))
+	batch, err := lenient.ExecuteColumnar("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Len != 1 || !reflect.DeepEqual(batch.Column("id"), []interface{}{int64(1)}) {
+		t.Errorf("batch = %+v, want only the complete row", batch)
+	}
+	if !strings.Contains(logs.String(), "truncated record") {
+		t.Errorf("no warning logged: %q", logs.String())
+	}
+
+	strict := connectMock(t, handle, WithStrictParsing(true))
+	var parseErr *ParseError
+	if _, err := strict.ExecuteColumnar("SELECT * FROM users"); !errors.As(err, &parseErr) {
+		t.Errorf("ExecuteColumnar() err = %v, want *ParseError", err)
+	}
+	if _, err := strict.ExecuteRows("SELECT * FROM users"); !errors.As(err, &parseErr) {
+		t.Errorf("ExecuteRows() err = %v, want *ParseError", err)
+	}
+}
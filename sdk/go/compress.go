@@ -0,0 +1,37 @@
+package poubelle
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"strings"
+)
+
+// negotiateCompression asks the server to compress its output. A server
+// that supports it answers with the line "Compression: gzip" and sends
+// everything after it, starting with the next prompt, as one gzip stream
+// flushed after each prompt. Any other answer, including an error from a
+// server that does not know the command, leaves the connection uncompressed.
+func (c *Client) negotiateCompression() error {
+	if err := c.writeLine("COMPRESS gzip"); err != nil {
+		return err
+	}
+
+	ack, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(ack) != "Compression: gzip" {
+		c.logf("poubelle: server does not support compression, continuing uncompressed")
+		return waitForPrompt(c.reader, "poubelle> ")
+	}
+
+	gz, err := gzip.NewReader(c.reader)
+	if err != nil {
+		return fmt.Errorf("compression negotiation failed: %v", err)
+	}
+	c.reader = bufio.NewReader(gz)
+	c.compressed = true
+
+	return waitForPrompt(c.reader, "poubelle> ")
+}
@@ -0,0 +1,73 @@
+package poubelle
+
+import (
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	result := wideResult(200)
+	s := &mockServer{handle: func(query string) string { return result }, compress: true}
+	s.start(t)
+
+	client, err := NewClient(s.dsn(), WithCompression(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		rows, err := client.Execute("SELECT * FROM users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !RowsEqual(rows, parseRows(result)) {
+			t.Fatalf("compressed response differs:\n%s", DiffRows(parseRows(result), rows))
+		}
+	}
+}
+
+func TestCompressionFallback(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "COMPRESS gzip" {
+			return "Error: Parse error: unexpected token\n"
+		}
+		return "{\"id\": Int(1)}\n"
+	}, WithCompression(true))
+
+	rows, err := client.Execute("SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != int64(1) {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func benchmarkCompression(b *testing.B, compress bool) {
+	result := wideResult(2000)
+	s := &mockServer{handle: func(query string) string { return result }, compress: compress}
+	s.start(b)
+
+	client, err := NewClient(s.dsn(), WithCompression(compress))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := client.Query("SELECT * FROM users"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQueryUncompressed(b *testing.B) { benchmarkCompression(b, false) }
+
+func BenchmarkQueryCompressed(b *testing.B) { benchmarkCompression(b, true) }
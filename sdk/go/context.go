@@ -0,0 +1,144 @@
+package poubelle
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// WithContext returns a shallow copy of c whose operations are bound to ctx.
+//
+// The copy shares c's configuration and connection, and operations on c and
+// all of its copies are serialized on that connection. Once ctx is done,
+// operations on the copy fail with ctx.Err() without touching the
+// connection. If ctx is cancelled while an operation of the copy is in
+// flight, that operation returns ctx.Err() and the shared connection is
+// closed, because the rest of its response can no longer be read in step;
+// c and other copies then need to Connect again.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("poubelle: nil context")
+	}
+
+	derived := *c
+	derived.ctx = ctx
+	return &derived
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// cancelSignal is the line that asks the server to abort the statement
+// running on the connection it arrives on, like Ctrl+C at a terminal.
+const cancelSignal = "\x03\n"
+
+// cancelWriteTimeout bounds how long sending cancelSignal may block.
+const cancelWriteTimeout = time.Second
+
+// QueryContext runs sql like Query, bound to ctx. If ctx is done while the
+// statement is in flight, the client first sends the server a cancel
+// signal on the connection so that it aborts the statement, as Cancel
+// does, then stops waiting for the response, closes the connection as
+// WithContext describes, and returns ctx.Err(). The signal is sent on a
+// best-effort basis: a server that does not honour it finishes the
+// statement on its own, but the call still returns promptly.
+func (c *Client) QueryContext(ctx context.Context, sql string) (string, error) {
+	derived := c.WithContext(ctx)
+	derived.cancelOnDone = true
+	return derived.Query(sql)
+}
+
+// Cancel asks the server to abort the statement currently in flight on c's
+// connection, if there is one. It does not wait for the statement to stop;
+// the call running it returns with whatever the server answers.
+//
+// The signal is sent only once the statement has been written in full, so
+// it never splits one. Because the server's answer to the signal could
+// otherwise be read as the next statement's response, the connection is
+// closed when the cancelled statement returns, and the client must connect
+// again, as after a cancelled context.
+func (c *Client) Cancel() error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.inFlight == nil {
+		return nil
+	}
+	if !c.written {
+		c.cancelWanted = true
+		return nil
+	}
+	return c.sendCancelLocked()
+}
+
+// markWritten records that the in-flight statement has been written and
+// sends a cancel requested meanwhile. It does nothing for a statement sent
+// outside an in-flight query, such as by ExecuteAsync.
+func (c *Client) markWritten() {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.inFlight == nil {
+		return
+	}
+	c.written = true
+	if c.cancelWanted {
+		c.cancelWanted = false
+		if err := c.sendCancelLocked(); err != nil {
+			c.logf("poubelle: sending cancel signal failed: %v", err)
+		}
+	}
+}
+
+// sendCancelLocked sends the cancel signal on the in-flight connection with
+// closeMu held.
+func (c *Client) sendCancelLocked() error {
+	c.cancelSent = true
+	return sendCancel(c.inFlight)
+}
+
+func sendCancel(conn net.Conn) error {
+	conn.SetWriteDeadline(time.Now().Add(cancelWriteTimeout))
+	_, err := io.WriteString(conn, cancelSignal)
+	conn.SetWriteDeadline(time.Time{})
+	return err
+}
+
+// watchContext interrupts blocked I/O on the current connection when ctx is
+// done, after sending a cancel signal if c came from QueryContext. The
+// returned function stops watching and reports ctx.Err() if the
+// interruption happened.
+func (c *Client) watchContext(ctx context.Context) func() error {
+	return c.watch(ctx, c.cancelOnDone)
+}
+
+// watch is watchContext with the cancel signal chosen by the caller, for
+// the handshake and goodbye, where there is no statement to cancel.
+func (c *Client) watch(ctx context.Context, cancel bool) func() error {
+	if ctx.Done() == nil {
+		return func() error { return nil }
+	}
+
+	conn := c.conn
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		if cancel {
+			if err := c.Cancel(); err != nil {
+				c.logf("poubelle: sending cancel signal failed: %v", err)
+			}
+		}
+		conn.SetDeadline(time.Unix(1, 0))
+		close(interrupted)
+	})
+
+	return func() error {
+		if stop() {
+			return nil
+		}
+		<-interrupted
+		return ctx.Err()
+	}
+}
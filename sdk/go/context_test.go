@@ -0,0 +1,291 @@
+package poubelle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithContextCancelledBeforeQuery(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "ok\n" })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	derived := client.WithContext(ctx)
+	if result, err := derived.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Fatalf("derived Query() = %q, %v", result, err)
+	}
+
+	cancel()
+	if _, err := derived.Query("SELECT 1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("derived Query() after cancel error = %v, want context.Canceled", err)
+	}
+	if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Errorf("parent Query() = %q, %v, want it unaffected", result, err)
+	}
+}
+
+func TestWithContextCancelInFlight(t *testing.T) {
+	client := connectMock(t, slowHandler(2*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	derived := client.WithContext(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := derived.Query("SELECT 1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Query() error = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("cancellation did not interrupt the in-flight query")
+	}
+	if _, err := client.Query("SELECT 1"); err == nil {
+		t.Error("expected shared connection to be closed after an interrupted query")
+	}
+}
+
+func TestWithContextDeadline(t *testing.T) {
+	client := connectMock(t, slowHandler(2*time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.WithContext(ctx).Execute("SELECT 1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// cancelServer answers the handshake, reads one statement without
+// answering it, and reports the next line it receives.
+func cancelServer(t *testing.T) (*mockServer, <-chan string) {
+	t.Helper()
+
+	received := make(chan string, 1)
+	s := &mockServer{handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+		if !defaultHandshake(conn, reader) {
+			return false
+		}
+		fmt.Fprint(conn, "poubelle> ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		line, _ := reader.ReadString('\n')
+		received <- line
+		return false
+	}}
+	s.start(t)
+	return s, received
+}
+
+func TestQueryContextSendsCancel(t *testing.T) {
+	s, received := cancelServer(t)
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if _, err := client.QueryContext(ctx, "SELECT * FROM big"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("QueryContext() error = %v, want context.Canceled", err)
+	}
+	select {
+	case line := <-received:
+		if line != cancelSignal {
+			t.Errorf("server received %q after the statement, want the cancel signal", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancel signal was not sent")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s, received := cancelServer(t)
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.Cancel(); err != nil {
+		t.Errorf("Cancel() with nothing in flight = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Query("SELECT * FROM big")
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-received; line != cancelSignal {
+		t.Errorf("server received %q, want the cancel signal", line)
+	}
+	if err := <-done; err == nil {
+		t.Error("Query() succeeded after the server dropped the connection")
+	}
+}
+
+// holdConn pauses the first Write after hold is set until release is
+// closed, to catch a Cancel while a statement is being written.
+type holdConn struct {
+	net.Conn
+	hold    atomic.Bool
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (c *holdConn) Write(p []byte) (int, error) {
+	if c.hold.CompareAndSwap(true, false) {
+		close(c.blocked)
+		<-c.release
+	}
+	return c.Conn.Write(p)
+}
+
+func TestCancelWaitsForStatementWrite(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	received := make(chan string, 2)
+	go func() {
+		defer serverSide.Close()
+		reader := bufio.NewReader(serverSide)
+		if !defaultHandshake(serverSide, reader) {
+			return
+		}
+		fmt.Fprint(serverSide, "poubelle> ")
+		for i := 0; i < 2; i++ {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	conn := &holdConn{Conn: clientSide, blocked: make(chan struct{}), release: make(chan struct{})}
+	client, err := NewClientFromConn(conn, "admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn.hold.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Query("SELECT * FROM big")
+		done <- err
+	}()
+	<-conn.blocked
+	if err := client.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	close(conn.release)
+
+	if line := <-received; line != "SELECT * FROM big\n" {
+		t.Errorf("server first received %q, want the whole statement", line)
+	}
+	if line := <-received; line != cancelSignal {
+		t.Errorf("server then received %q, want the cancel signal", line)
+	}
+	<-done
+}
+
+func TestCancelDropsConnection(t *testing.T) {
+	started := make(chan struct{}, 1)
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "SELECT slow":
+			started <- struct{}{}
+			time.Sleep(100 * time.Millisecond)
+			return "slow done\n"
+		case strings.TrimSpace(cancelSignal):
+			return "Error: Parse error: unexpected character\n"
+		default:
+			return "answer to " + query + "\n"
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		result, err := client.Query("SELECT slow")
+		if err == nil && result != "slow done" {
+			err = fmt.Errorf("result = %q", result)
+		}
+		done <- err
+	}()
+	<-started
+	if err := client.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("cancelled Query() = %v", err)
+	}
+
+	if _, err := client.Query("SELECT 2"); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("Query() after Cancel error = %v, want ErrNotConnected", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.Query("SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "answer to SELECT 2" {
+		t.Errorf("next Query() = %q, want its own answer, not the reply to the cancel signal", result)
+	}
+}
+
+func TestCancelAfterExecuteAsync(t *testing.T) {
+	started := make(chan struct{}, 1)
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "INSERT slow":
+			started <- struct{}{}
+			time.Sleep(100 * time.Millisecond)
+			return "Row inserted\n"
+		case strings.TrimSpace(cancelSignal):
+			return "Error: Parse error: unexpected character\n"
+		default:
+			return "answer to " + query + "\n"
+		}
+	})
+
+	if err := client.ExecuteAsync("INSERT slow"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		result, err := client.Query("SELECT 1")
+		if err == nil && result != "answer to SELECT 1" {
+			err = fmt.Errorf("result = %q, want the statement's own answer", result)
+		}
+		done <- err
+	}()
+	// Cancel while the query is still draining the async response, before
+	// its statement is written.
+	time.Sleep(30 * time.Millisecond)
+	if err := client.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// ErrCursorClosed is returned by Fetch on a cursor that has been closed.
+var ErrCursorClosed = errors.New("cursor is closed")
+
+var cursorSeq atomic.Uint64
+
+// Cursor streams the result of a query from a server-side cursor, a batch
+// at a time, so the result never has to fit in memory on either end.
+type Cursor struct {
+	c         *Client
+	name      string
+	batchSize int
+	done      bool
+	closed    bool
+}
+
+// Cursor declares a server-side cursor for sql with DECLARE ... CURSOR FOR.
+// Each Fetch reads the next batchSize rows. The cursor must be closed with
+// Close to free it on the server.
+func (c *Client) Cursor(sql string, batchSize int) (*Cursor, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("cursor batch size must be positive, got %d", batchSize)
+	}
+
+	if err := c.checkFeature(featureCursors); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("poubelle_cursor_%d", cursorSeq.Add(1))
+	if err := c.ExecuteDDL(fmt.Sprintf("DECLARE %s CURSOR FOR %s", name, sql)); err != nil {
+		return nil, err
+	}
+	return &Cursor{c: c, name: name, batchSize: batchSize}, nil
+}
+
+// Fetch returns the next batch of rows with FETCH. It returns io.EOF once
+// the cursor is exhausted; the final batch may hold fewer than batchSize
+// rows.
+func (cur *Cursor) Fetch() ([]Row, error) {
+	if cur.closed {
+		return nil, ErrCursorClosed
+	}
+	if cur.done {
+		return nil, io.EOF
+	}
+
+	result, err := cur.c.do(fmt.Sprintf("FETCH %d FROM %s", cur.batchSize, cur.name))
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+
+	rows, err := cur.c.decodeRows(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < cur.batchSize {
+		cur.done = true
+	}
+	if len(rows) == 0 {
+		return nil, io.EOF
+	}
+	return rows, nil
+}
+
+// Close deallocates the cursor on the server with CLOSE. Closing a cursor
+// twice does nothing.
+func (cur *Cursor) Close() error {
+	if cur.closed {
+		return nil
+	}
+	cur.closed = true
+	return cur.c.ExecuteDDL("CLOSE " + cur.name)
+}
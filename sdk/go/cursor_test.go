@@ -0,0 +1,126 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cursorServer serves DECLARE, FETCH and CLOSE over a table of n rows.
+type cursorServer struct {
+	mu      sync.Mutex
+	n       int
+	cursors map[string]int
+	closed  []string
+}
+
+var (
+	declareRe = regexp.MustCompile(`^DECLARE (\w+) CURSOR FOR SELECT \* FROM big$`)
+	fetchRe   = regexp.MustCompile(`^FETCH (\d+) FROM (\w+)$`)
+)
+
+func (s *cursorServer) handle(query string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m := declareRe.FindStringSubmatch(query); m != nil {
+		s.cursors[m[1]] = 0
+		return "DECLARE CURSOR\n"
+	}
+	if m := fetchRe.FindStringSubmatch(query); m != nil {
+		pos, ok := s.cursors[m[2]]
+		if !ok {
+			return "Error: cursor " + m[2] + " does not exist\n"
+		}
+		count, _ := strconv.Atoi(m[1])
+		if pos == s.n {
+			return "No rows\n"
+		}
+		var out strings.Builder
+		for ; count > 0 && pos < s.n; count-- {
+			pos++
+			fmt.Fprintf(&out, "{\"id\": Int(%d)}\n", pos)
+		}
+		s.cursors[m[2]] = pos
+		return out.String()
+	}
+	if name, ok := strings.CutPrefix(query, "CLOSE "); ok {
+		delete(s.cursors, name)
+		s.closed = append(s.closed, name)
+		return "CLOSE CURSOR\n"
+	}
+	return fmt.Sprintf("Error: unexpected query %q\n", query)
+}
+
+func TestCursor(t *testing.T) {
+	for _, n := range []int{7, 9} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			s := &cursorServer{n: n, cursors: map[string]int{}}
+			client := connectMock(t, s.handle)
+
+			cur, err := client.Cursor("SELECT * FROM big", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var batches []int
+			next := int64(1)
+			for {
+				rows, err := cur.Fetch()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				batches = append(batches, len(rows))
+				for _, row := range rows {
+					if row["id"] != next {
+						t.Fatalf("row %v, want id %d", row, next)
+					}
+					next++
+				}
+			}
+			if next != int64(n+1) {
+				t.Errorf("read %d rows, want %d", next-1, n)
+			}
+			if len(batches) != (n+2)/3 {
+				t.Errorf("batches = %v", batches)
+			}
+			if _, err := cur.Fetch(); err != io.EOF {
+				t.Errorf("Fetch() after the end: err = %v, want io.EOF", err)
+			}
+
+			if err := cur.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := cur.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if len(s.closed) != 1 || len(s.cursors) != 0 {
+				t.Errorf("cursor not deallocated exactly once: closed %v", s.closed)
+			}
+			if _, err := cur.Fetch(); !errors.Is(err, ErrCursorClosed) {
+				t.Errorf("Fetch() after Close: err = %v, want ErrCursorClosed", err)
+			}
+		})
+	}
+}
+
+func TestCursorDeclareError(t *testing.T) {
+	s := &cursorServer{cursors: map[string]int{}}
+	client := connectMock(t, s.handle)
+
+	var serverErr *ServerError
+	if _, err := client.Cursor("SELECT * FROM other", 10); !errors.As(err, &serverErr) {
+		t.Errorf("err = %v, want *ServerError", err)
+	}
+	if _, err := client.Cursor("SELECT * FROM big", 0); err == nil {
+		t.Error("expected an error for a zero batch size")
+	}
+}
@@ -0,0 +1,299 @@
+package poubelle
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ColumnInfo describes a table column as reported by DescribeTable.
+type ColumnInfo struct {
+	Name string
+	Type string
+}
+
+// ListTables returns the names of all tables, sorted, from the server's
+// __tables__ meta-table.
+func (c *Client) ListTables() ([]string, error) {
+	rows, err := c.queryRows("SELECT * FROM __tables__")
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(rows))
+	for _, row := range rows {
+		name, ok := row["name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("__tables__ row has no name column: %v", row)
+		}
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// DescribeTable returns the columns of table, in the order the server lists
+// them, from DESCRIBE table, whose rows carry a name and a type column.
+func (c *Client) DescribeTable(table string) ([]ColumnInfo, error) {
+	quoted, err := c.reservedWords.quoteIdent(table)
+	if err != nil {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	result, err := c.do("DESCRIBE " + quoted)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+
+	var cols []ColumnInfo
+	for _, row := range parseRows(result) {
+		name, _ := row["name"].(string)
+		typ, _ := row["type"].(string)
+		if name == "" || typ == "" {
+			return nil, fmt.Errorf("DESCRIBE %s returned a row without name and type: %v", table, row)
+		}
+		cols = append(cols, ColumnInfo{Name: name, Type: typ})
+	}
+	return cols, nil
+}
+
+// Dump writes a script that recreates every table and its rows: a CREATE
+// TABLE statement per table followed by an INSERT per row, one statement per
+// line. Restore and ExecuteScript replay it. A text value the server cannot
+// represent in a literal, one holding a quote or a line break, fails the
+// dump.
+func (c *Client) Dump(w io.Writer) error {
+	tables, err := c.ListTables()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "-- Poubelle dump")
+	for _, table := range tables {
+		if err := c.dumpTable(bw, table); err != nil {
+			return fmt.Errorf("dump %s: %w", table, err)
+		}
+	}
+	return bw.Flush()
+}
+
+func (c *Client) dumpTable(w io.Writer, table string) error {
+	cols, err := c.DescribeTable(table)
+	if err != nil {
+		return err
+	}
+
+	quotedTable, err := c.reservedWords.quoteIdent(table)
+	if err != nil {
+		return err
+	}
+	defs := make([]string, len(cols))
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		name, err := c.reservedWords.quoteIdent(col.Name)
+		if err != nil {
+			return fmt.Errorf("invalid column name %q", col.Name)
+		}
+		defs[i] = name + " " + col.Type
+		names[i] = name
+	}
+	fmt.Fprintf(w, "CREATE TABLE %s (%s)\n", quotedTable, strings.Join(defs, ", "))
+
+	rows, err := c.queryRows("SELECT * FROM " + quotedTable)
+	if err != nil {
+		return err
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", quotedTable, strings.Join(names, ", "))
+	values := make([]string, len(cols))
+	for _, row := range rows {
+		for i, col := range cols {
+			v, _ := row.Get(col.Name)
+			literal, err := formatLiteral(v, c.quoteStyle)
+			if err != nil {
+				return fmt.Errorf("column %s: %v", col.Name, err)
+			}
+			values[i] = literal
+		}
+		fmt.Fprintf(w, "%s%s)\n", prefix, strings.Join(values, ", "))
+	}
+	return nil
+}
+
+// queryRows runs sql in the debug format and parses its rows, returning a
+// server error as a *ServerError rather than as no rows.
+func (c *Client) queryRows(sql string) ([]Row, error) {
+	result, err := c.queryArgs(sql, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+	return c.decodeRows(result)
+}
+
+// Restore runs the script read from r, as written by Dump. See
+// ExecuteScript.
+func (c *Client) Restore(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		stmt := strings.TrimSpace(scanner.Text())
+		if stmt == "" || strings.HasPrefix(stmt, "--") {
+			continue
+		}
+		if err := c.ExecuteDDL(stmt); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// ExecuteScript runs script one line at a time, as the server reads one
+// statement per line. Blank lines and lines starting with -- are skipped,
+// since the server does not understand comments. It stops at the first
+// statement that fails or that the server answers with an error.
+func (c *Client) ExecuteScript(script string) error {
+	return c.Restore(strings.NewReader(script))
+}
+
+// RestoreJSONL inserts the rows read from r, one JSON object per line, into
+// table and returns the number of rows loaded. Each key names a column;
+// strings become TEXT, integers INT and null NULL, with values quoted as in
+// QueryParams. Rows are sent in batches as in BulkInsert, which takes the
+// same options, and blank lines are skipped.
+//
+// A line that is not a JSON object of such values stops the restore before
+// its batch is sent, with an error naming the line. A row the server
+// rejects stops it after its batch, with a *BatchError indexed from the
+// first row, wrapped in an error naming the line of the first rejected row.
+func (c *Client) RestoreJSONL(table string, r io.Reader, opts ...BulkOption) (int64, error) {
+	cfg := newBulkConfig(opts)
+	if !isIdentifier(table) {
+		return 0, fmt.Errorf("invalid table name %q", table)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var loaded int64
+	var batch []string
+	var lines []int
+	flush := func() error {
+		n, err := c.insertBatch(batch, int(loaded))
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			err = fmt.Errorf("line %d: %w", lines[batchErr.Failures[0].Index-int(loaded)], err)
+		}
+		loaded += n
+		batch, lines = batch[:0], lines[:0]
+		if err == nil && cfg.progress != nil {
+			cfg.progress(loaded)
+		}
+		return err
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		stmt, err := c.jsonlInsert(table, text)
+		if err != nil {
+			return loaded, fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, stmt)
+		lines = append(lines, line)
+		if len(batch) == cfg.batchSize {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// jsonlInsert returns the INSERT for one JSON-lines record, with its
+// columns in sorted order.
+func (c *Client) jsonlInsert(table, text string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var record map[string]interface{}
+	if err := dec.Decode(&record); err != nil {
+		return "", fmt.Errorf("malformed record: %v", err)
+	}
+	if dec.More() {
+		return "", errors.New("malformed record: data after the object")
+	}
+	if len(record) == 0 {
+		return "", errors.New("record has no columns")
+	}
+
+	cols := make([]string, 0, len(record))
+	for col := range record {
+		if !isIdentifier(col) {
+			return "", fmt.Errorf("invalid column name %q", col)
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	names, err := c.reservedWords.quoteIdents(cols)
+	if err != nil {
+		return "", err
+	}
+	quotedTable, err := c.reservedWords.quoteIdent(table)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]string, len(cols))
+	for i, col := range cols {
+		v, err := jsonlValue(record[col])
+		if err != nil {
+			return "", fmt.Errorf("column %s: %v", col, err)
+		}
+		if values[i], err = formatLiteral(v, c.quoteStyle); err != nil {
+			return "", fmt.Errorf("column %s: %v", col, err)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quotedTable, strings.Join(names, ", "), strings.Join(values, ", ")), nil
+}
+
+// jsonlValue converts a decoded JSON value to one formatLiteral accepts.
+func jsonlValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string:
+		return v, nil
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an integer", v)
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unsupported JSON value %v", v)
+	}
+}
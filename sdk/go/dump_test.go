@@ -0,0 +1,314 @@
+package poubelle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memDB is a tiny in-memory store for the mock server that understands
+// just the statements Dump and Restore use.
+type memDB struct {
+	mu    sync.Mutex
+	order []string
+	cols  map[string][]ColumnInfo
+	rows  map[string][]string
+}
+
+var (
+	createRe  = regexp.MustCompile(`^CREATE TABLE (\w+) \((.*)\)$`)
+	insertRe  = regexp.MustCompile(`^INSERT INTO (\w+) \(([^)]*)\) VALUES \((.*)\)$`)
+	literalRe = regexp.MustCompile(`'[^']*'|NULL|-?\d+`)
+)
+
+func newMemDB() *memDB {
+	return &memDB{cols: map[string][]ColumnInfo{}, rows: map[string][]string{}}
+}
+
+func (db *memDB) handle(query string) string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if m := createRe.FindStringSubmatch(query); m != nil {
+		db.order = append(db.order, m[1])
+		for _, def := range strings.Split(m[2], ", ") {
+			name, typ, _ := strings.Cut(def, " ")
+			db.cols[m[1]] = append(db.cols[m[1]], ColumnInfo{Name: name, Type: typ})
+		}
+		return "Table created\n"
+	}
+	if m := insertRe.FindStringSubmatch(query); m != nil {
+		names := strings.Split(m[2], ", ")
+		literals := literalRe.FindAllString(m[3], -1)
+		fields := make([]string, len(names))
+		for i, name := range names {
+			v := "Null"
+			switch lit := literals[i]; {
+			case strings.HasPrefix(lit, "'"):
+				v = fmt.Sprintf("Text(%q)", strings.Trim(lit, "'"))
+			case lit != "NULL":
+				v = "Int(" + lit + ")"
+			}
+			fields[i] = fmt.Sprintf("%q: %s", name, v)
+		}
+		db.rows[m[1]] = append(db.rows[m[1]], "{"+strings.Join(fields, ", ")+"}")
+		return "Row inserted\n"
+	}
+	if query == "SELECT * FROM __tables__" {
+		var out strings.Builder
+		for _, name := range db.order {
+			fmt.Fprintf(&out, "{\"name\": Text(%q)}\n", name)
+		}
+		return out.String()
+	}
+	if table, ok := strings.CutPrefix(query, "DESCRIBE "); ok {
+		var out strings.Builder
+		for _, col := range db.cols[table] {
+			fmt.Fprintf(&out, "{\"name\": Text(%q), \"type\": Text(%q)}\n", col.Name, col.Type)
+		}
+		return out.String()
+	}
+	if table, ok := strings.CutPrefix(query, "SELECT * FROM "); ok {
+		if len(db.rows[table]) == 0 {
+			return "No rows\n"
+		}
+		return strings.Join(db.rows[table], "\n") + "\n"
+	}
+	return fmt.Sprintf("Error: unexpected query %q\n", query)
+}
+
+func TestDumpRestore(t *testing.T) {
+	src := newMemDB()
+	client := connectMock(t, src.handle)
+	err := client.ExecuteScript(`
+-- fixture
+CREATE TABLE users (id INT, name TEXT)
+INSERT INTO users (id, name) VALUES (1, 'Alice')
+INSERT INTO users (id, name) VALUES (2, NULL)
+CREATE TABLE empty (id INT)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dump bytes.Buffer
+	if err := client.Dump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	want := `-- Poubelle dump
+CREATE TABLE empty (id INT)
+CREATE TABLE users (id INT, name TEXT)
+INSERT INTO users (id, name) VALUES (1, 'Alice')
+INSERT INTO users (id, name) VALUES (2, NULL)
+`
+	if dump.String() != want {
+		t.Errorf("dump:\n%s\nwant:\n%s", dump.String(), want)
+	}
+
+	dst := newMemDB()
+	restored := connectMock(t, dst.handle)
+	if err := restored.Restore(&dump); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"users", "empty"} {
+		a, err := client.Execute("SELECT * FROM " + table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := restored.Execute("SELECT * FROM " + table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !RowsEqual(a, b) {
+			t.Errorf("%s differs after restore:\n%s", table, DiffRows(a, b))
+		}
+	}
+	if !reflect.DeepEqual(src.cols, dst.cols) {
+		t.Errorf("columns = %v, want %v", dst.cols, src.cols)
+	}
+}
+
+func TestDumpReservedWords(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		switch query {
+		case "SELECT * FROM __tables__":
+			return "{\"name\": Text(\"order\")}\n"
+		case `DESCRIBE "order"`:
+			return "{\"name\": Text(\"text\"), \"type\": Text(\"TEXT\")}\n"
+		case `SELECT * FROM "order"`:
+			return "{\"text\": Text(\"a\")}\n"
+		}
+		return "Error: unexpected statement\n"
+	}, WithReservedWords(append(Keywords(), "ORDER")))
+
+	var dump bytes.Buffer
+	if err := client.Dump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	want := `-- Poubelle dump
+CREATE TABLE "order" ("text" TEXT)
+INSERT INTO "order" ("text") VALUES ('a')
+`
+	if dump.String() != want {
+		t.Errorf("dump:\n%s\nwant:\n%s\nqueries: %q", dump.String(), want, log.all())
+	}
+}
+
+func TestDumpFailedSelect(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "SELECT * FROM __tables__":
+			return "{\"name\": Text(\"users\")}\n"
+		case "DESCRIBE users":
+			return "{\"name\": Text(\"id\"), \"type\": Text(\"INT\")}\n"
+		}
+		return "Error: Table 'users' is locked\n"
+	})
+
+	var serverErr *ServerError
+	if err := client.Dump(io.Discard); !errors.As(err, &serverErr) {
+		t.Errorf("Dump() error = %v, want the *ServerError of the failed SELECT", err)
+	}
+}
+
+func TestDumpRejectedTables(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "Error: Table '__tables__' not found\n"
+	})
+
+	var serverErr *ServerError
+	if _, err := client.ListTables(); !errors.As(err, &serverErr) {
+		t.Errorf("ListTables() error = %v, want *ServerError", err)
+	}
+	var dump bytes.Buffer
+	if err := client.Dump(&dump); !errors.As(err, &serverErr) {
+		t.Errorf("Dump() error = %v, want *ServerError", err)
+	}
+}
+
+func TestDumpColumnCase(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "SELECT * FROM __tables__":
+			return "{\"name\": Text(\"users\")}\n"
+		case "DESCRIBE users":
+			return "{\"name\": Text(\"Name\"), \"type\": Text(\"TEXT\")}\n"
+		}
+		return "{\"name\": Text(\"Alice\")}\n"
+	})
+
+	var dump bytes.Buffer
+	if err := client.Dump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO users (Name) VALUES ('Alice')\n"; !strings.HasSuffix(dump.String(), want) {
+		t.Errorf("dump:\n%s\nwant it to end with %q", dump.String(), want)
+	}
+}
+
+func TestRestoreStopsAtError(t *testing.T) {
+	client := connectMock(t, newMemDB().handle)
+	err := client.Restore(strings.NewReader("CREATE TABLE t (id INT)\n\nDROP TABLE t\nCREATE TABLE u (id INT)\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Fatalf("err = %v, want an error on line 3", err)
+	}
+	if tables, _ := client.ListTables(); len(tables) != 1 {
+		t.Errorf("tables = %v, want only t", tables)
+	}
+}
+
+func TestRestoreJSONL(t *testing.T) {
+	db := newMemDB()
+	client := connectMock(t, db.handle)
+
+	input := `{"id": 1, "name": "Alice"}
+{"name": "Bob", "id": 2}
+
+{"id": 3, "name": null}
+`
+	var progress []int64
+	n, err := client.RestoreJSONL("users", strings.NewReader(input),
+		WithBulkBatchSize(2), WithBulkProgress(func(loaded int64) { progress = append(progress, loaded) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("loaded %d rows, want 3", n)
+	}
+	if !reflect.DeepEqual(progress, []int64{2, 3}) {
+		t.Errorf("progress = %v, want [2 3]", progress)
+	}
+
+	rows, err := client.Execute("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{
+		{"id": int64(1), "name": "Alice"},
+		{"id": int64(2), "name": "Bob"},
+		{"id": int64(3), "name": nil},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestRestoreJSONLErrors(t *testing.T) {
+	client := connectMock(t, newMemDB().handle)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"{\"id\": 1}\n{\"id\": 2,\n", "line 2: malformed record"},
+		{"\n[1, 2]\n", "line 2: malformed record"},
+		{"{\"id\": 1.5}\n", "line 1: column id: 1.5 is not an integer"},
+		{"{\"ok\": true}\n", "line 1: column ok: unsupported JSON value true"},
+		{"{\"bad-name\": 1}\n", "line 1: invalid column name"},
+		{"{}\n", "line 1: record has no columns"},
+	}
+	for _, tt := range tests {
+		n, err := client.RestoreJSONL("t", strings.NewReader(tt.input))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("RestoreJSONL(%q) = %v, want %q", tt.input, err, tt.want)
+		}
+		if n != 0 {
+			t.Errorf("RestoreJSONL(%q) loaded %d rows", tt.input, n)
+		}
+	}
+	if _, err := client.RestoreJSONL("bad table", strings.NewReader("")); err == nil {
+		t.Error("invalid table name accepted")
+	}
+}
+
+func TestRestoreJSONLRejectedRow(t *testing.T) {
+	db := newMemDB()
+	client := connectMock(t, func(query string) string {
+		if strings.Contains(query, "'bad'") {
+			return "Error: rejected\n"
+		}
+		return db.handle(query)
+	})
+
+	input := "{\"name\": \"a\"}\n{\"name\": \"b\"}\n{\"name\": \"bad\"}\n{\"name\": \"c\"}\n"
+	n, err := client.RestoreJSONL("t", strings.NewReader(input), WithBulkBatchSize(2))
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Fatalf("err = %v, want a *BatchError on line 3", err)
+	}
+	if batchErr.Failures[0].Index != 2 {
+		t.Errorf("failure index = %d, want 2", batchErr.Failures[0].Index)
+	}
+	if n != 3 {
+		t.Errorf("loaded %d rows, want 3", n)
+	}
+}
@@ -0,0 +1,90 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotConnected is returned by operations on a client that has no
+// connection, because Connect was never called or the connection was
+// dropped.
+var ErrNotConnected = errors.New("not connected")
+
+// ErrNotAuthenticated is returned by operations on a client whose last
+// Connect did not complete authentication. Nothing is sent to the server.
+var ErrNotAuthenticated = errors.New("not authenticated")
+
+// ErrHandshakeTimeout is returned by Connect when the server does not
+// complete the handshake within the time set with WithHandshakeTimeout.
+var ErrHandshakeTimeout = errors.New("handshake timed out")
+
+// ErrAuthenticationFailed is returned by Connect when the server rejects
+// the credentials.
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
+// ErrConnectionClosed is returned when the server has closed the connection,
+// for example after an idle timeout. The client must Connect again unless
+// auto-reconnect is enabled.
+var ErrConnectionClosed = errors.New("connection closed by server")
+
+// ErrPartialWrite is returned when a write failed after part of a
+// statement had been sent. The server may have received half a line, so
+// the connection is out of step and is dropped; the client must Connect
+// again unless auto-reconnect is enabled. The statement is not resent.
+var ErrPartialWrite = errors.New("statement only partly written")
+
+// ErrClientClosed is returned by a query interrupted by Close, and by
+// queries made after Close.
+var ErrClientClosed = errors.New("client closed")
+
+// ErrEmptyQuery is returned, without contacting the server, for a statement
+// that is empty or holds only whitespace and comments.
+var ErrEmptyQuery = errors.New("empty query")
+
+// ErrQueryTooLong is returned, without contacting the server, for a
+// statement longer than the limit set with WithMaxQueryLength.
+var ErrQueryTooLong = errors.New("query too long")
+
+// ErrNoRows is returned by QueryRow and QueryScalar when the query returns
+// no rows.
+var ErrNoRows = errors.New("no rows in result")
+
+// ResponseTooLargeError is returned when a query response exceeds the limit
+// set with WithMaxResponseSize.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds maximum size of %d bytes", e.Limit)
+}
+
+// ParseError is returned when a response cannot be decoded, such as a row
+// naming the same column twice under WithStrictParsing.
+type ParseError struct {
+	Record string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error: %s in %q", e.Reason, e.Record)
+}
+
+// ServerError is an error reported by the server in response to a
+// statement, from an "Error: ..." acknowledgment.
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return "server error: " + e.Message
+}
+
+// ackError returns a *ServerError if result is an error acknowledgment.
+func ackError(result string) error {
+	if msg, ok := strings.CutPrefix(result, "Error: "); ok {
+		return &ServerError{Message: msg}
+	}
+	return nil
+}
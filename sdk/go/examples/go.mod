@@ -1,6 +1,6 @@
 module example
 
-go 1.21
+go 1.25.1
 
 replace github.com/poubelle/sdk-go => ../
 
@@ -0,0 +1,194 @@
+package poubelle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Format is an output format the server can answer a SELECT in.
+type Format int32
+
+const (
+	// FormatDebug is the server's default, debug-printed rows.
+	FormatDebug Format = iota
+	FormatJSON
+	FormatTable
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "JSON"
+	case FormatTable:
+		return "TABLE"
+	default:
+		return "DEBUG"
+	}
+}
+
+// ParseFormat returns the format named s, case-insensitively: debug, json
+// or table.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToUpper(s) {
+	case "DEBUG":
+		return FormatDebug, nil
+	case "JSON":
+		return FormatJSON, nil
+	case "TABLE":
+		return FormatTable, nil
+	}
+	return FormatDebug, fmt.Errorf("unknown format %q, want debug, json or table", s)
+}
+
+// formatClauseRe matches a FORMAT clause ending a statement.
+var formatClauseRe = regexp.MustCompile(`(?i)\bFORMAT\s+[A-Z]+\s*;?\s*$`)
+
+// withFormat appends a FORMAT clause for f to sql, unless f is the server's
+// default, sql already ends with a FORMAT clause, or sql is not a read, which
+// the server would reject with the clause.
+func withFormat(sql string, f Format) string {
+	if f == FormatDebug || formatClauseRe.MatchString(sql) || ClassifyStatement(sql) != Read {
+		return sql
+	}
+	return strings.TrimRight(sql, " \t\r\n") + " FORMAT " + f.String()
+}
+
+// SetDefaultFormat sets the format Query, QueryParams and QueryTagged ask
+// the server for from now on, by appending a FORMAT clause to reads. It
+// applies to the client and every copy made with WithContext. The methods
+// that parse a result, such as Execute or ExecuteJSON, always ask for the
+// format they parse.
+//
+// Precedence, highest first: a FORMAT clause in the statement itself, the
+// format passed to ExecuteFormat, the default set here, and the server's
+// debug format.
+func (c *Client) SetDefaultFormat(f Format) {
+	c.defaultFormat.Store(int32(f))
+}
+
+// DefaultFormat returns the format set with SetDefaultFormat.
+func (c *Client) DefaultFormat() Format {
+	return Format(c.defaultFormat.Load())
+}
+
+// ExecuteFormat runs sql, binding any args as QueryParams does, with the
+// server asked for format f regardless of the default, and returns the raw
+// result.
+func (c *Client) ExecuteFormat(sql string, f Format, args ...interface{}) (string, error) {
+	stmt, err := c.bindArgs(sql, args)
+	if err != nil {
+		return "", err
+	}
+	return c.do(withFormat(stmt, f))
+}
+
+// WithDefaultFormat fixes the format the server answers in to f, as the
+// format= connection string parameter does. It sets the default format
+// like SetDefaultFormat, and Execute asks for f and parses the response in
+// it without looking at the response to guess: a JSON array for FormatJSON,
+// an aligned table for FormatTable, debug rows for FormatDebug. A response
+// that is not in the format is an error, never parsed some other way.
+func WithDefaultFormat(f Format) Option {
+	return func(c *Client) {
+		c.fixedFormat = f
+		c.hasFixedFormat = true
+		c.defaultFormat.Store(int32(f))
+	}
+}
+
+// parseDSNParams applies the parameters of a connection string's query
+// string to cfg.
+func parseDSNParams(cfg *connConfig, query string) error {
+	if query == "" {
+		return nil
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return dsnError("bad parameters: %v", err)
+	}
+	for key, values := range params {
+		if key != "format" {
+			return dsnError("unknown parameter %q", key)
+		}
+		f, err := ParseFormat(values[len(values)-1])
+		if err != nil {
+			return dsnError("parameter format: %v", err)
+		}
+		cfg.format, cfg.formatSet = f, true
+	}
+	return nil
+}
+
+// executeFixed runs Execute for a client whose format is fixed to JSON or
+// table.
+func (c *Client) executeFixed(sql string, args []interface{}) ([]Row, error) {
+	if c.fixedFormat == FormatTable {
+		rs, err := c.ExecuteTable(sql, args...)
+		if err != nil {
+			return nil, err
+		}
+		return rs.Rows, nil
+	}
+
+	result, err := c.queryJSON(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+	if c.recordStatus(result) {
+		return []Row{}, nil
+	}
+	rows := []Row{}
+	if err := json.Unmarshal([]byte(result), &rows); err != nil {
+		return nil, &ParseError{Record: result, Reason: "response is not a JSON array of rows"}
+	}
+	return rows, nil
+}
+
+// WithAutoDetectFormat makes Execute accept a JSON response as well as the
+// debug format it asks for, for servers configured to answer in JSON by
+// default. A response that starts with [ or { and parses as JSON is read as
+// an array of rows, or a single row for an object; anything else goes to the
+// debug parser. Debug-format rows are never valid JSON, so the two cannot be
+// confused. A format fixed with WithDefaultFormat takes precedence.
+func WithAutoDetectFormat(enabled bool) Option {
+	return func(c *Client) {
+		c.autoDetectFormat = enabled
+	}
+}
+
+// isJSONResponse reports whether result is a JSON array or object, which
+// debug-format rows never are.
+func isJSONResponse(result string) bool {
+	trimmed := strings.TrimSpace(result)
+	return (strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")) && json.Valid([]byte(trimmed))
+}
+
+// detectJSONRows parses result as JSON rows if it is JSON, and reports
+// whether it was.
+func (c *Client) detectJSONRows(result string) ([]Row, bool) {
+	trimmed := strings.TrimSpace(result)
+	if !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	if c.recordStatus(trimmed) {
+		return []Row{}, true
+	}
+	if trimmed[0] == '{' {
+		var row Row
+		if err := json.Unmarshal([]byte(trimmed), &row); err != nil {
+			return nil, false
+		}
+		return []Row{row}, true
+	}
+	rows := []Row{}
+	if err := json.Unmarshal([]byte(trimmed), &rows); err != nil {
+		return nil, false
+	}
+	return rows, true
+}
@@ -0,0 +1,169 @@
+package poubelle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFormat(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "{\"id\": Int(1)}\n"
+	})
+
+	client.Query("SELECT * FROM t")
+	client.SetDefaultFormat(FormatJSON)
+	client.Query("SELECT * FROM t")
+	client.QueryParams("SELECT * FROM t WHERE id = ?", 1)
+	client.Query("SELECT * FROM t FORMAT TABLE")
+	client.Query("INSERT INTO t (id) VALUES (1)")
+	client.ExecuteFormat("SELECT * FROM t", FormatDebug)
+	client.ExecuteFormat("SELECT * FROM t", FormatTable)
+	if rows, err := client.Execute("SELECT * FROM t"); err != nil || len(rows) != 1 {
+		t.Errorf("Execute() with a JSON default = %v, %v", rows, err)
+	}
+	client.WithContext(t.Context()).Query("SELECT * FROM t")
+	client.SetDefaultFormat(FormatDebug)
+	client.Query("SELECT * FROM t")
+
+	want := []string{
+		"SELECT * FROM t",
+		"SELECT * FROM t FORMAT JSON",
+		"SELECT * FROM t WHERE id = 1 FORMAT JSON",
+		"SELECT * FROM t FORMAT TABLE",
+		"INSERT INTO t (id) VALUES (1)",
+		"SELECT * FROM t",
+		"SELECT * FROM t FORMAT TABLE",
+		"SELECT * FROM t",
+		"SELECT * FROM t FORMAT JSON",
+		"SELECT * FROM t",
+	}
+	if got := log.all(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestFixedFormat(t *testing.T) {
+	handle := func(query string) string {
+		switch {
+		case strings.HasSuffix(query, "FORMAT JSON"):
+			return `[{"id":1,"note":"{\"id\": Int(9)}"}]` + "\n"
+		case strings.HasSuffix(query, "FORMAT TABLE"):
+			return " id | note\n----+-----\n 1  | hi\n"
+		}
+		return `{"id": Int(1), "note": Text("[1]")}` + "\n"
+	}
+	s := newMockServer(t, handle)
+
+	tests := []struct {
+		dsn  string
+		opts []Option
+		want []Row
+	}{
+		{s.dsn() + "?format=json", nil, []Row{{"id": float64(1), "note": `{"id": Int(9)}`}}},
+		{s.dsn(), []Option{WithDefaultFormat(FormatJSON)}, []Row{{"id": float64(1), "note": `{"id": Int(9)}`}}},
+		{s.dsn() + "/?format=TABLE", nil, []Row{{"id": int64(1), "note": "hi"}}},
+		{s.dsn() + "?format=debug", nil, []Row{{"id": int64(1), "note": "[1]"}}},
+		{s.dsn(), []Option{WithDefaultFormat(FormatDebug)}, []Row{{"id": int64(1), "note": "[1]"}}},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(tt.dsn, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := client.Execute("SELECT * FROM t")
+		if err != nil {
+			t.Errorf("%s: %v", tt.dsn, err)
+		} else if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("%s: rows = %v, want %v", tt.dsn, rows, tt.want)
+		}
+		client.Close()
+	}
+}
+
+func TestFixedFormatRejectsOtherFormats(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return `{"id": Int(1)}` + "\n"
+	}, WithDefaultFormat(FormatJSON))
+
+	var parseErr *ParseError
+	if _, err := client.Execute("SELECT * FROM t"); !errors.As(err, &parseErr) {
+		t.Errorf("Execute() = %v, want *ParseError for a debug response", err)
+	}
+}
+
+func TestFormatDSNParameter(t *testing.T) {
+	cfg, err := parseConnectionString("poubelle://admin:secret@db:5432?format=json")
+	if err != nil || !cfg.formatSet || cfg.format != FormatJSON || cfg.hosts[0].host != "db" {
+		t.Errorf("parseConnectionString() = %+v, %v", cfg, err)
+	}
+	for _, dsn := range []string{
+		"poubelle://admin:secret@db:5432?format=xml",
+		"poubelle://admin:secret@db:5432?timeout=5s",
+		"poubelle://admin:secret@db:5432?format=%zz",
+	} {
+		if _, err := parseConnectionString(dsn); err == nil {
+			t.Errorf("parseConnectionString(%q) succeeded, want error", dsn)
+		}
+	}
+}
+
+func TestAutoDetectFormat(t *testing.T) {
+	responses := map[string]string{
+		"SELECT json":   `[{"id":1,"name":"Alice"},{"id":2,"name":null}]`,
+		"SELECT object": `{"id":3}`,
+		"SELECT empty":  `[]`,
+		"SELECT debug":  `{"id": Int(1), "name": Text("[not json]")}` + "\n" + `{"id": Int(2), "name": Null}`,
+		"SELECT none":   "No rows",
+	}
+	client := connectMock(t, func(query string) string { return responses[query] + "\n" }, WithAutoDetectFormat(true))
+
+	tests := []struct {
+		sql  string
+		want []Row
+	}{
+		{"SELECT json", []Row{{"id": float64(1), "name": "Alice"}, {"id": float64(2), "name": nil}}},
+		{"SELECT object", []Row{{"id": float64(3)}}},
+		{"SELECT empty", []Row{}},
+		{"SELECT debug", []Row{{"id": int64(1), "name": "[not json]"}, {"id": int64(2), "name": nil}}},
+		{"SELECT none", []Row{}},
+	}
+	for _, tt := range tests {
+		rows, err := client.Execute(tt.sql)
+		if err != nil {
+			t.Errorf("Execute(%q): %v", tt.sql, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("Execute(%q) = %v, want %v", tt.sql, rows, tt.want)
+		}
+	}
+
+	plain := connectMock(t, func(query string) string { return responses[query] + "\n" })
+	if rows, err := plain.Execute("SELECT json"); err != nil || len(rows) != 0 {
+		t.Errorf("Execute() without detection = %v, %v, want no rows", rows, err)
+	}
+}
+
+func TestAutoDetectFormatWithFixedDebug(t *testing.T) {
+	responses := map[string]string{
+		"SELECT json":  `[{"id":1}]`,
+		"SELECT debug": `{"id": Int(1)}`,
+	}
+	client := connectMock(t, func(query string) string { return responses[query] + "\n" },
+		WithDefaultFormat(FormatDebug), WithAutoDetectFormat(true))
+
+	var parseErr *ParseError
+	if rows, err := client.Execute("SELECT json"); !errors.As(err, &parseErr) {
+		t.Errorf("Execute(json) = %v, %v, want *ParseError since the format is fixed to debug", rows, err)
+	}
+	if rows, err := client.Execute("SELECT debug"); err != nil || !reflect.DeepEqual(rows, []Row{{"id": int64(1)}}) {
+		t.Errorf("Execute(debug) = %v, %v", rows, err)
+	}
+}
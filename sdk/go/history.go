@@ -0,0 +1,48 @@
+package poubelle
+
+// WithQueryHistory keeps the last n statements sent by the client, oldest
+// first, for History to return after a failure. Memory is bounded to n
+// statements. Zero, the default, keeps none.
+func WithQueryHistory(n int) Option {
+	return func(c *Client) {
+		c.historySize = n
+	}
+}
+
+// WithHistoryRedaction records statements in the query history with their
+// string literals, including bound QueryParams values, replaced by ?.
+func WithHistoryRedaction(enabled bool) Option {
+	return func(c *Client) {
+		c.redactHistory = enabled
+	}
+}
+
+// History returns the statements recorded with WithQueryHistory, oldest
+// first. It waits for an in-flight statement to finish.
+func (c *Client) History() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.history) < c.historySize {
+		return append([]string(nil), c.history...)
+	}
+	out := make([]string, 0, len(c.history))
+	out = append(out, c.history[c.historyNext:]...)
+	return append(out, c.history[:c.historyNext]...)
+}
+
+// recordHistory adds sql to the history ring, with c.mu held.
+func (c *Client) recordHistory(sql string) {
+	if c.historySize <= 0 {
+		return
+	}
+	if c.redactHistory {
+		sql = sanitizeStatement(sql)
+	}
+	if len(c.history) < c.historySize {
+		c.history = append(c.history, sql)
+		return
+	}
+	c.history[c.historyNext] = sql
+	c.historyNext = (c.historyNext + 1) % c.historySize
+}
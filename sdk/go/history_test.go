@@ -0,0 +1,72 @@
+package poubelle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestQueryHistory(t *testing.T) {
+	client := connectMock(t, func(string) string { return "ok\n" }, WithQueryHistory(3))
+
+	if got := client.History(); len(got) != 0 {
+		t.Errorf("History() before any query = %q", got)
+	}
+	for i := 1; i <= 5; i++ {
+		if _, err := client.Query(fmt.Sprintf("SELECT %d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			if got, want := client.History(), []string{"SELECT 1", "SELECT 2"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("History() = %q, want %q", got, want)
+			}
+		}
+	}
+
+	want := []string{"SELECT 3", "SELECT 4", "SELECT 5"}
+	if got := client.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+}
+
+func TestQueryHistoryAsync(t *testing.T) {
+	client := connectMock(t, func(string) string { return "ok\n" }, WithQueryHistory(4))
+
+	if err := client.ExecuteAsync("INSERT INTO logs VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Execute("SELECT * FROM logs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ExecuteAsync("INSERT INTO logs VALUES (2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"INSERT INTO logs VALUES (1)", "SELECT * FROM logs", "INSERT INTO logs VALUES (2)"}
+	if got := client.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+}
+
+func TestQueryHistoryRedaction(t *testing.T) {
+	client := connectMock(t, func(string) string { return "ok\n" },
+		WithQueryHistory(2), WithHistoryRedaction(true))
+
+	if _, err := client.QueryParams("SELECT * FROM users WHERE name = ?", "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SELECT * FROM users WHERE name = ?"}
+	if got := client.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+}
+
+func TestQueryHistoryDisabled(t *testing.T) {
+	client := connectMock(t, func(string) string { return "ok\n" })
+	if _, err := client.Query("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.History(); len(got) != 0 {
+		t.Errorf("History() = %q, want none by default", got)
+	}
+}
@@ -0,0 +1,316 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Result is the outcome of a statement that returns an acknowledgment
+// rather than rows.
+type Result struct {
+	// Message is the server's acknowledgment, such as "Row inserted".
+	Message string
+	// RowsAffected is the row count taken from Message, or 0 if it names
+	// none.
+	RowsAffected int64
+}
+
+var affectedRe = regexp.MustCompile(`(?i)\b(\d+) rows?\b`)
+
+func newResult(ack string) Result {
+	r := Result{Message: ack}
+	if m := affectedRe.FindStringSubmatch(ack); m != nil {
+		r.RowsAffected, _ = strconv.ParseInt(m[1], 10, 64)
+	} else if strings.HasPrefix(strings.ToLower(ack), "row ") {
+		r.RowsAffected = 1
+	}
+	return r
+}
+
+// InsertStruct inserts v, a struct or a pointer to one, as a row of table.
+// Each exported field is a column named by its `poubelle:"name"` tag, or by
+// its name in lower case if it has none. Fields tagged `poubelle:"-"` are
+// skipped, and the fields of embedded structs are included.
+//
+// Zero values are inserted as they are, so an int field left at 0 stores 0.
+// To leave a column out when its field is zero, letting the server apply
+// NULL, tag it with omitempty: `poubelle:"name,omitempty"`. A nil pointer
+// field always stores NULL. Values are bound as in QueryParams, and table
+// and column names that are reserved words are quoted, as with
+// WithReservedWords.
+func (c *Client) InsertStruct(table string, v interface{}) (Result, error) {
+	if !isIdentifier(table) {
+		return Result{}, fmt.Errorf("invalid table name %q", table)
+	}
+	cols, args, err := structColumns(v)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(cols) == 0 {
+		return Result{}, fmt.Errorf("%T has no columns to insert", v)
+	}
+
+	stmt, err := c.insertStatement(table, cols)
+	if err != nil {
+		return Result{}, err
+	}
+	result, err := c.queryArgs(stmt, args)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := ackError(result); err != nil {
+		return Result{}, err
+	}
+	return newResult(result), nil
+}
+
+// InsertReturning runs sql, an INSERT, and returns the identifiers the
+// server generated for the new rows, in the order it lists them. The IDs may
+// come back as records, as with a RETURNING clause, where each record's "id"
+// column is used, or its first column if it has no "id"; or in the
+// acknowledgment, as in "3 rows inserted, ids: 4, 5, 6". If the server
+// reports no IDs the slice is empty and the error nil.
+func (c *Client) InsertReturning(sql string, args ...interface{}) ([]int64, error) {
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+	return returnedIDs(result)
+}
+
+var ackIDsRe = regexp.MustCompile(`(?i)\bids?\b:?\s*(\d+(?:\s*,\s*\d+)*)`)
+
+// returnedIDs collects the generated IDs from an INSERT response, as
+// described on InsertReturning.
+func returnedIDs(result string) ([]int64, error) {
+	ids := []int64{}
+	for line := range recordLines(result) {
+		if !isRecord(line) {
+			if m := ackIDsRe.FindStringSubmatch(line); m != nil {
+				for _, field := range strings.Split(m[1], ",") {
+					id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid returned id %q: %w", field, err)
+					}
+					ids = append(ids, id)
+				}
+			}
+			continue
+		}
+
+		var raw string
+		n := 0
+		for key, value := range rowFields(line) {
+			n++
+			if key == "id" || n == 1 {
+				raw = value
+			}
+			if key == "id" {
+				break
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		v := parseTypedValue(raw)
+		if v.Kind != KindInt {
+			return nil, fmt.Errorf("returned id %s is not an integer", raw)
+		}
+		ids = append(ids, v.Int)
+	}
+	return ids, nil
+}
+
+// insertStatement returns an INSERT of one row into the given columns of
+// table, with a placeholder per column and reserved words quoted.
+func (c *Client) insertStatement(table string, cols []string) (string, error) {
+	quotedTable, err := c.reservedWords.quoteIdent(table)
+	if err != nil {
+		return "", err
+	}
+	quoted, err := c.reservedWords.quoteIdents(cols)
+	if err != nil {
+		return "", err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quotedTable, strings.Join(quoted, ", "), placeholders), nil
+}
+
+// structColumns returns the column names and values of the struct v, as
+// described on InsertStruct.
+func structColumns(v interface{}) ([]string, []interface{}, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil, fmt.Errorf("cannot insert a nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("cannot insert %T, want a struct", v)
+	}
+
+	var cols []string
+	var args []interface{}
+	for _, f := range reflect.VisibleFields(rv.Type()) {
+		if !f.IsExported() || (f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag := f.Tag.Get("poubelle")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if !isIdentifier(name) {
+			return nil, nil, fmt.Errorf("field %s: invalid column name %q", f.Name, name)
+		}
+
+		fv := rv.FieldByIndex(f.Index)
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		cols = append(cols, name)
+		args = append(args, fieldValue(fv))
+	}
+	return cols, args, nil
+}
+
+// fieldValue returns fv in a form formatLiteral accepts: nil for a nil
+// pointer, the pointed-to value otherwise, and the underlying kind for
+// named integer and string types.
+func fieldValue(fv reflect.Value) interface{} {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	switch {
+	case fv.CanInt():
+		return fv.Int()
+	case fv.CanUint():
+		return fv.Uint()
+	case fv.Kind() == reflect.String:
+		return fv.String()
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		return fv.Bytes()
+	}
+	return fv.Interface()
+}
+
+// defaultBulkBatchSize is the number of rows BulkInsert sends per round
+// trip unless WithBulkBatchSize says otherwise.
+const defaultBulkBatchSize = 100
+
+// BulkOption configures a BulkInsert or RestoreJSONL.
+type BulkOption func(*bulkConfig)
+
+type bulkConfig struct {
+	batchSize int
+	progress  func(rowsLoaded int64)
+}
+
+func newBulkConfig(opts []BulkOption) bulkConfig {
+	cfg := bulkConfig{batchSize: defaultBulkBatchSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithBulkBatchSize sets how many rows BulkInsert sends per round trip.
+// The default is 100.
+func WithBulkBatchSize(n int) BulkOption {
+	return func(b *bulkConfig) {
+		if n > 0 {
+			b.batchSize = n
+		}
+	}
+}
+
+// WithBulkProgress calls fn with the total number of rows loaded so far
+// after each batch. fn runs on the goroutine calling BulkInsert, between
+// batches, while the connection is free for other goroutines.
+func WithBulkProgress(fn func(rowsLoaded int64)) BulkOption {
+	return func(b *bulkConfig) {
+		b.progress = fn
+	}
+}
+
+// BulkInsert inserts rows into the given columns of table, one INSERT per
+// row since the server takes a single row per statement. Rows are sent in
+// batches with ExecuteBatch, one round trip each, and values are bound as
+// in QueryParams. The connection is held for one batch at a time.
+//
+// It returns the number of rows loaded. If a row is rejected, the rest of
+// its batch is still loaded, no further batch is sent, and the error is a
+// *BatchError whose indexes count from the first row of rows.
+func (c *Client) BulkInsert(table string, columns []string, rows [][]interface{}, opts ...BulkOption) (int64, error) {
+	cfg := newBulkConfig(opts)
+	if !isIdentifier(table) {
+		return 0, fmt.Errorf("invalid table name %q", table)
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns to insert into %s", table)
+	}
+	for _, col := range columns {
+		if !isIdentifier(col) {
+			return 0, fmt.Errorf("invalid column name %q", col)
+		}
+	}
+	stmt, err := c.insertStatement(table, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var loaded int64
+	for start := 0; start < len(rows); start += cfg.batchSize {
+		end := min(start+cfg.batchSize, len(rows))
+		batch := make([]string, 0, end-start)
+		for i, row := range rows[start:end] {
+			sql, err := c.bindArgs(stmt, row)
+			if err != nil {
+				return loaded, fmt.Errorf("row %d: %w", start+i, err)
+			}
+			batch = append(batch, sql)
+		}
+
+		n, err := c.insertBatch(batch, start)
+		loaded += n
+		if err != nil {
+			return loaded, err
+		}
+		if cfg.progress != nil {
+			cfg.progress(loaded)
+		}
+	}
+	return loaded, nil
+}
+
+// insertBatch sends batch, the INSERTs for the rows counted from start,
+// with ExecuteBatch and returns how many were loaded. A *BatchError has
+// its indexes offset by start.
+func (c *Client) insertBatch(batch []string, start int) (int64, error) {
+	_, err := c.ExecuteBatch(batch)
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		for i := range batchErr.Failures {
+			batchErr.Failures[i].Index += start
+		}
+		return int64(len(batch) - len(batchErr.Failures)), batchErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(batch)), nil
+}
@@ -0,0 +1,232 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type base struct {
+	ID int `poubelle:"id"`
+}
+
+type user struct {
+	base
+	Name     string  `poubelle:"name"`
+	Nickname *string `poubelle:"nickname"`
+	Age      int     `poubelle:"age,omitempty"`
+	Email    string
+	Session  string `poubelle:"-"`
+	secret   string
+}
+
+func TestInsertStruct(t *testing.T) {
+	db := newMemDB()
+	client := connectMock(t, db.handle)
+
+	nick := "al"
+	res, err := client.InsertStruct("users", &user{base: base{ID: 1}, Name: "Alice", Nickname: &nick, Age: 30, Email: "a@x", Session: "tok", secret: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected != 1 || res.Message != "Row inserted" {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := client.InsertStruct("users", user{base: base{ID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := client.Execute("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{
+		{"id": int64(1), "name": "Alice", "nickname": "al", "age": int64(30), "email": "a@x"},
+		{"id": int64(2), "name": "", "nickname": nil, "email": ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestInsertStructErrors(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "Error: table missing\n" })
+
+	var serverErr *ServerError
+	if _, err := client.InsertStruct("users", user{}); !errors.As(err, &serverErr) {
+		t.Errorf("server error = %v, want *ServerError", err)
+	}
+	if _, err := client.InsertStruct("users; DROP", user{}); err == nil {
+		t.Error("expected invalid table name error")
+	}
+	if _, err := client.InsertStruct("users", 42); err == nil {
+		t.Error("expected error for a non-struct")
+	}
+	if _, err := client.InsertStruct("users", (*user)(nil)); err == nil {
+		t.Error("expected error for a nil pointer")
+	}
+	if _, err := client.InsertStruct("users", struct {
+		Bad string `poubelle:"a b"`
+	}{}); err == nil {
+		t.Error("expected invalid column name error")
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	for ack, want := range map[string]int64{
+		"Row inserted":   1,
+		"3 rows deleted": 3,
+		"Table created":  0,
+	} {
+		if got := newResult(ack).RowsAffected; got != want {
+			t.Errorf("newResult(%q).RowsAffected = %d, want %d", ack, got, want)
+		}
+	}
+}
+
+func TestBulkInsertProgress(t *testing.T) {
+	db := newMemDB()
+	client := connectMock(t, db.handle)
+	if err := client.ExecuteDDL("CREATE TABLE items (id INT, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := make([][]interface{}, 250)
+	for i := range rows {
+		rows[i] = []interface{}{i, fmt.Sprintf("item %d", i)}
+	}
+	var progress []int64
+	n, err := client.BulkInsert("items", []string{"id", "name"}, rows,
+		WithBulkBatchSize(100),
+		WithBulkProgress(func(loaded int64) {
+			// The connection is free between batches.
+			if _, err := client.Query("SELECT * FROM __tables__"); err != nil {
+				t.Error(err)
+			}
+			progress = append(progress, loaded)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 250 {
+		t.Errorf("loaded %d rows, want 250", n)
+	}
+	if want := []int64{100, 200, 250}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	got, err := client.Execute("SELECT * FROM items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 250 || got[249]["name"] != "item 249" {
+		t.Errorf("read back %d rows, last %v", len(got), got[len(got)-1])
+	}
+}
+
+func TestBulkInsertFailure(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if strings.Contains(query, "(3, ") {
+			return "Error: duplicate key\n"
+		}
+		return "Row inserted\n"
+	})
+
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	var progress []int64
+	n, err := client.BulkInsert("items", []string{"id", "name"}, rows,
+		WithBulkBatchSize(2), WithBulkProgress(func(loaded int64) { progress = append(progress, loaded) }))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failures) != 1 || batchErr.Failures[0].Index != 2 {
+		t.Fatalf("err = %v, want a *BatchError for row 2", err)
+	}
+	if n != 3 {
+		t.Errorf("loaded %d rows, want 3", n)
+	}
+	if want := []int64{2}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	if _, err := client.BulkInsert("items", []string{"id"}, [][]interface{}{{1, 2}}); err == nil {
+		t.Error("expected an error for a row with too many values")
+	}
+	if _, err := client.BulkInsert("items", []string{"id; DROP"}, rows); err == nil {
+		t.Error("expected an error for an invalid column name")
+	}
+}
+
+func TestInsertReturning(t *testing.T) {
+	responses := map[string]string{
+		"INSERT INTO users (name) VALUES ('a') RETURNING id":   "{\"id\": Int(4)}\n{\"id\": Int(5)}\n{\"id\": Int(6)}\n",
+		"INSERT INTO users (name) VALUES ('b')":                "3 rows inserted, ids: 7, 8, 9\n",
+		"INSERT INTO users (name) VALUES ('c') RETURNING *":    "{\"name\": Text(\"c\"), \"id\": Int(10)}\n",
+		"INSERT INTO users (name) VALUES ('d')":                "Row inserted\n",
+		"INSERT INTO users (name) VALUES ('e') RETURNING name": "{\"name\": Text(\"e\")}\n",
+	}
+	client := connectMock(t, func(query string) string {
+		if resp, ok := responses[query]; ok {
+			return resp
+		}
+		return "Error: unexpected query\n"
+	})
+
+	tests := []struct {
+		sql  string
+		want []int64
+	}{
+		{"INSERT INTO users (name) VALUES (?) RETURNING id", []int64{4, 5, 6}},
+		{"INSERT INTO users (name) VALUES (?)", []int64{7, 8, 9}},
+		{"INSERT INTO users (name) VALUES (?) RETURNING *", []int64{10}},
+		{"INSERT INTO users (name) VALUES (?)", []int64{}},
+	}
+	for i, tt := range tests {
+		arg := string(rune('a' + i))
+		ids, err := client.InsertReturning(tt.sql, arg)
+		if err != nil {
+			t.Fatalf("%s: %v", arg, err)
+		}
+		if ids == nil || !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: ids = %#v, want %v", arg, ids, tt.want)
+		}
+	}
+
+	if _, err := client.InsertReturning("INSERT INTO users (name) VALUES (?) RETURNING name", "e"); err == nil || !strings.Contains(err.Error(), "not an integer") {
+		t.Errorf("err = %v, want a text id rejected", err)
+	}
+
+	var serverErr *ServerError
+	if _, err := client.InsertReturning("INSERT INTO nope (x) VALUES (1)"); !errors.As(err, &serverErr) {
+		t.Errorf("err = %v, want *ServerError", err)
+	}
+}
+
+func TestInsertReservedWordColumns(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "Row inserted\n"
+	}, WithReservedWords(append(Keywords(), "ORDER", "user")))
+
+	type item struct {
+		Order int    `poubelle:"order"`
+		User  string `poubelle:"user"`
+		Name  string `poubelle:"name"`
+	}
+	if _, err := client.InsertStruct("order", item{Order: 1, User: "al", Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BulkInsert("items", []string{"order", "limit"}, [][]interface{}{{2, 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`INSERT INTO "order" ("order", "user", name) VALUES (1, 'al', 'x')`,
+		`INSERT INTO items ("order", "limit") VALUES (2, 10)`,
+	}
+	if got := log.all(); !reflect.DeepEqual(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
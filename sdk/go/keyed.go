@@ -0,0 +1,100 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrDuplicateKey is returned by ExecuteMap and ExecuteMapBy when two rows
+// have the same key and WithLastWins was not given.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// MapOption configures ExecuteMap and ExecuteMapBy.
+type MapOption func(*mapConfig)
+
+type mapConfig struct {
+	lastWins bool
+	args     []interface{}
+}
+
+// WithLastWins keeps the last of the rows sharing a key instead of failing
+// with ErrDuplicateKey.
+func WithLastWins() MapOption {
+	return func(m *mapConfig) {
+		m.lastWins = true
+	}
+}
+
+// WithArgs binds args to the ? placeholders of the statement, as the
+// trailing args of Execute do.
+func WithArgs(args ...interface{}) MapOption {
+	return func(m *mapConfig) {
+		m.args = append(m.args, args...)
+	}
+}
+
+// ExecuteMap runs sql and returns its rows indexed by the value of
+// keyColumn, looked up as in Row.Get. Keys are int64 or string as decoded
+// by Execute; integers too large for int64 are keyed by their decimal
+// string. A row without the column, or with NULL in it, is an error, and
+// so is a key shared by two rows unless WithLastWins is given. Arguments
+// for placeholders in sql are passed with WithArgs.
+func (c *Client) ExecuteMap(sql, keyColumn string, opts ...MapOption) (map[interface{}]Row, error) {
+	return executeMap(c, sql, keyColumn, opts, func(v interface{}) (interface{}, error) {
+		if n, ok := v.(*big.Int); ok {
+			return n.String(), nil
+		}
+		return v, nil
+	})
+}
+
+// ExecuteMapBy is ExecuteMap with keys of type K. Each key is converted as
+// Rows.Scan converts values, so an INT column can key a map[int] and any
+// column a map[string].
+func ExecuteMapBy[K comparable](c *Client, sql, keyColumn string, opts ...MapOption) (map[K]Row, error) {
+	return executeMap(c, sql, keyColumn, opts, func(v interface{}) (K, error) {
+		var key K
+		err := convertAssign(&key, v)
+		return key, err
+	})
+}
+
+func executeMap[K comparable](c *Client, sql, keyColumn string, opts []MapOption, toKey func(interface{}) (K, error)) (map[K]Row, error) {
+	var cfg mapConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	result, err := c.queryArgs(sql, cfg.args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+	rows, err := c.decodeRows(result)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[K]Row, len(rows))
+	for i, row := range rows {
+		v, ok := row.Get(keyColumn)
+		if !ok {
+			return nil, fmt.Errorf("row %d has no key column %s", i, keyColumn)
+		}
+		if v == nil {
+			return nil, fmt.Errorf("row %d has NULL in key column %s", i, keyColumn)
+		}
+		key, err := toKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: key column %s: %w", i, keyColumn, err)
+		}
+		if _, dup := m[key]; dup && !cfg.lastWins {
+			return nil, fmt.Errorf("%w %v in column %s at row %d", ErrDuplicateKey, key, keyColumn, i)
+		}
+		m[key] = row
+	}
+	return m, nil
+}
@@ -0,0 +1,130 @@
+package poubelle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func keyedClient(t *testing.T) *Client {
+	t.Helper()
+	return connectMock(t, func(query string) string {
+		switch query {
+		case "SELECT * FROM users", "SELECT * FROM users WHERE name = 'Alice'":
+			if strings.Contains(query, "Alice") {
+				return "{\"id\": Int(1), \"name\": Text(\"Alice\")}\n"
+			}
+			return "{\"id\": Int(1), \"name\": Text(\"Alice\")}\n{\"id\": Int(2), \"name\": Text(\"Bob\")}\n"
+		case "SELECT * FROM dupes":
+			return "{\"id\": Int(1), \"name\": Text(\"first\")}\n{\"id\": Int(1), \"name\": Text(\"second\")}\n"
+		case "SELECT * FROM holes":
+			return "{\"id\": Int(1)}\n{\"name\": Text(\"no id\")}\n"
+		case "SELECT * FROM nulls":
+			return "{\"id\": Null}\n"
+		}
+		return "Error: no such table\n"
+	})
+}
+
+func TestExecuteMap(t *testing.T) {
+	client := keyedClient(t)
+
+	m, err := client.ExecuteMap("SELECT * FROM users", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]Row{
+		int64(1): {"id": int64(1), "name": "Alice"},
+		int64(2): {"id": int64(2), "name": "Bob"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("map = %v, want %v", m, want)
+	}
+
+	byName, err := client.ExecuteMap("SELECT * FROM users", "NAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := byName["Bob"]; row == nil || row["id"] != int64(2) {
+		t.Errorf("map by name = %v", byName)
+	}
+
+	if _, err := client.ExecuteMap("SELECT * FROM holes", "id"); err == nil || !strings.Contains(err.Error(), "row 1 has no key column id") {
+		t.Errorf("missing key column error = %v", err)
+	}
+	if _, err := client.ExecuteMap("SELECT * FROM nulls", "id"); err == nil || !strings.Contains(err.Error(), "NULL") {
+		t.Errorf("NULL key error = %v", err)
+	}
+	var serverErr *ServerError
+	if _, err := client.ExecuteMap("SELECT * FROM nope", "id"); !errors.As(err, &serverErr) {
+		t.Errorf("server error = %v, want *ServerError", err)
+	}
+}
+
+func TestExecuteMapArgs(t *testing.T) {
+	client := keyedClient(t)
+
+	m, err := client.ExecuteMap("SELECT * FROM users WHERE name = ?", "id", WithArgs("Alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[interface{}]Row{int64(1): {"id": int64(1), "name": "Alice"}}; !reflect.DeepEqual(m, want) {
+		t.Errorf("map = %v, want %v", m, want)
+	}
+
+	byID, err := ExecuteMapBy[int](client, "SELECT * FROM users WHERE name = ?", "id", WithArgs("Alice"), WithLastWins())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byID) != 1 || byID[1]["name"] != "Alice" {
+		t.Errorf("ExecuteMapBy = %v", byID)
+	}
+
+	if _, err := client.ExecuteMap("SELECT * FROM users WHERE name = ?", "id", WithArgs("Alice", "Bob")); err == nil {
+		t.Error("ExecuteMap with too many args: expected error")
+	}
+}
+
+func TestExecuteMapDuplicateKeys(t *testing.T) {
+	client := keyedClient(t)
+
+	if _, err := client.ExecuteMap("SELECT * FROM dupes", "id"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("duplicate key error = %v, want ErrDuplicateKey", err)
+	}
+	if _, err := ExecuteMapBy[int](client, "SELECT * FROM dupes", "id"); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("ExecuteMapBy duplicate key error = %v, want ErrDuplicateKey", err)
+	}
+
+	m, err := client.ExecuteMap("SELECT * FROM dupes", "id", WithLastWins())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m[int64(1)]["name"] != "second" {
+		t.Errorf("map with last wins = %v, want the second row", m)
+	}
+}
+
+func TestExecuteMapBy(t *testing.T) {
+	client := keyedClient(t)
+
+	byID, err := ExecuteMapBy[int](client, "SELECT * FROM users", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byID) != 2 || byID[1]["name"] != "Alice" || byID[2]["name"] != "Bob" {
+		t.Errorf("map[int] = %v", byID)
+	}
+
+	asString, err := ExecuteMapBy[string](client, "SELECT * FROM users", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asString["2"]["name"] != "Bob" {
+		t.Errorf("map[string] = %v", asString)
+	}
+
+	if _, err := ExecuteMapBy[int64](client, "SELECT * FROM users", "name"); err == nil {
+		t.Error("text keys converted to int64")
+	}
+}
@@ -0,0 +1,8 @@
+package poubelle
+
+// TestingT is the subset of testing.TB used by VerifyNoLeaks.
+type TestingT interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...interface{})
+}
@@ -0,0 +1,87 @@
+//go:build poubelle_leakcheck
+
+package poubelle
+
+import (
+	"log"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"weak"
+)
+
+// Leak checking is compiled in with -tags poubelle_leakcheck. Every
+// connection is recorded with the stack that opened it until Close is
+// called, and one garbage collected while still open logs a warning.
+// Tracking is per session, so copies made with WithContext count once.
+
+var (
+	leakMu     sync.Mutex
+	openClient = map[weak.Pointer[session]]string{}
+)
+
+func trackClient(c *Client) {
+	wp := weak.Make(c.session)
+
+	leakMu.Lock()
+	_, tracked := openClient[wp]
+	openClient[wp] = string(debug.Stack())
+	leakMu.Unlock()
+
+	if !tracked {
+		runtime.AddCleanup(c.session, reportCollected, wp)
+	}
+}
+
+func untrackClient(c *Client) {
+	leakMu.Lock()
+	delete(openClient, weak.Make(c.session))
+	leakMu.Unlock()
+}
+
+func reportCollected(wp weak.Pointer[session]) {
+	leakMu.Lock()
+	stack, open := openClient[wp]
+	delete(openClient, wp)
+	leakMu.Unlock()
+
+	if open {
+		log.Printf("poubelle: client garbage collected without Close; connected at:\n%s", stack)
+	}
+}
+
+// LeakedClients returns the connecting stack of every client that is
+// connected and not yet closed.
+func LeakedClients() []string {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+
+	stacks := make([]string, 0, len(openClient))
+	for _, stack := range openClient {
+		stacks = append(stacks, stack)
+	}
+	return stacks
+}
+
+// VerifyNoLeaks fails t at cleanup if a client connected during the test is
+// still open.
+func VerifyNoLeaks(t TestingT) {
+	t.Helper()
+
+	leakMu.Lock()
+	before := make(map[weak.Pointer[session]]bool, len(openClient))
+	for wp := range openClient {
+		before[wp] = true
+	}
+	leakMu.Unlock()
+
+	t.Cleanup(func() {
+		leakMu.Lock()
+		defer leakMu.Unlock()
+		for wp, stack := range openClient {
+			if !before[wp] {
+				t.Errorf("poubelle client was not closed; connected at:\n%s", stack)
+			}
+		}
+	})
+}
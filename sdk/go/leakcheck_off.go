@@ -0,0 +1,17 @@
+//go:build !poubelle_leakcheck
+
+package poubelle
+
+func trackClient(c *Client) {}
+
+func untrackClient(c *Client) {}
+
+// LeakedClients reports open clients when built with -tags
+// poubelle_leakcheck. Otherwise it always returns nil.
+func LeakedClients() []string {
+	return nil
+}
+
+// VerifyNoLeaks fails t if a client connected during the test is still open.
+// It only has an effect when built with -tags poubelle_leakcheck.
+func VerifyNoLeaks(t TestingT) {}
@@ -0,0 +1,50 @@
+//go:build poubelle_leakcheck
+
+package poubelle
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingT struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (r *recordingT) Cleanup(fn func()) { r.cleanups = append(r.cleanups, fn) }
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+
+	rt := &recordingT{TB: t}
+	VerifyNoLeaks(rt)
+
+	closed, _ := NewClient(s.dsn())
+	if err := closed.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	leaked, _ := NewClient(s.dsn())
+	if err := leaked.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer leaked.Close()
+
+	rt.finish()
+	if len(rt.errors) != 1 {
+		t.Errorf("expected exactly one leak report, got %d", len(rt.errors))
+	}
+}
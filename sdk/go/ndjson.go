@@ -0,0 +1,136 @@
+package poubelle
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExecuteNDJSON runs sql and writes each row to w as a compact JSON object
+// followed by a newline. The response is read a line at a time and each row
+// is encoded as soon as it arrives, so the result is never held in memory
+// whole. Numbers are written as json.Number with the digits the server
+// sent, so integers beyond the float64 range keep their precision.
+//
+// The statement is not retried, since rows may already have been written.
+func (c *Client) ExecuteNDJSON(sql string, w io.Writer, args ...interface{}) error {
+	stmt, err := c.bindArgs(sql, args)
+	if err != nil {
+		return err
+	}
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &ndjsonStream{client: c, enc: json.NewEncoder(w), row: make(map[string]interface{})}
+	s.enc.SetEscapeHTML(false)
+	if _, err := c.sendStatement(ctx, stmt, false, s.line); err != nil {
+		return err
+	}
+	return s.finish()
+}
+
+// ndjsonStream encodes the rows of a response read a line at a time.
+type ndjsonStream struct {
+	client  *Client
+	enc     *json.Encoder
+	row     map[string]interface{}
+	records recordSplitter
+	started bool
+	failure *strings.Builder
+}
+
+// line takes the next line of the response. Notices are handled as they
+// come, and a response starting with an error acknowledgment is collected
+// to be returned by finish.
+func (s *ndjsonStream) line(line string) error {
+	if notice, ok := parseNotice(strings.TrimSpace(line)); ok {
+		if s.client.noticeHandler != nil {
+			s.client.noticeHandler(notice)
+		}
+		return nil
+	}
+	if s.failure != nil {
+		s.failure.WriteString(line)
+		return nil
+	}
+	if !s.started {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+		s.started = true
+		if msg, ok := strings.CutPrefix(strings.TrimLeft(line, " \t\r\n"), "Error: "); ok {
+			s.failure = &strings.Builder{}
+			s.failure.WriteString(msg)
+			return nil
+		}
+	}
+
+	if record, ok := s.records.add(line); ok {
+		return s.encode(record)
+	}
+	return nil
+}
+
+// finish encodes what remains once the response has been read.
+func (s *ndjsonStream) finish() error {
+	if s.failure != nil {
+		return &ServerError{Message: strings.TrimSpace(s.failure.String())}
+	}
+	for _, line := range s.records.rest() {
+		if err := s.encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encode writes the row held by one record line, skipping lines that hold
+// none.
+func (s *ndjsonStream) encode(line string) error {
+	c := s.client
+	if isTruncatedRecord(line) {
+		return c.truncatedRecord(line)
+	}
+	if !isRecord(line) {
+		return nil
+	}
+	clear(s.row)
+	for key, raw := range rowFields(line) {
+		if _, ok := s.row[key]; ok {
+			if err := c.duplicateColumn(key, line); err != nil {
+				return err
+			}
+		}
+		s.row[key] = jsonValue(parseTypedValue(raw))
+	}
+	if len(s.row) == 0 {
+		return nil
+	}
+	return s.enc.Encode(s.row)
+}
+
+// jsonValue returns v in a form encoding/json writes without losing
+// precision.
+func jsonValue(v TypedValue) interface{} {
+	switch v.Kind {
+	case KindInt:
+		return json.Number(strconv.FormatInt(v.Int, 10))
+	case KindBigInt:
+		return json.Number(v.Big.String())
+	case KindFloat:
+		return json.Number(strconv.FormatFloat(v.Float, 'g', -1, 64))
+	case KindList:
+		list := make([]interface{}, len(v.List))
+		for i, elem := range v.List {
+			list[i] = jsonValue(elem)
+		}
+		return list
+	}
+	return v.Interface()
+}
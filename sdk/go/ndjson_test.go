@@ -0,0 +1,124 @@
+package poubelle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecuteNDJSON(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return `{"id": Int(1), "name": Text("Al <al@x>"), "big": Int(123456789012345678901234567890)}
+{"id": Int(2), "name": Null, "score": Float(0.1), "tags": List([Int(9007199254740993), Text("a")])}
+`
+	})
+
+	var buf bytes.Buffer
+	if err := client.ExecuteNDJSON("SELECT * FROM users", &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"big":123456789012345678901234567890,"id":1,"name":"Al <al@x>"}
+{"id":2,"name":null,"score":0.1,"tags":[9007199254740993,"a"]}
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestExecuteNDJSONErrors(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "SELECT * FROM dup" {
+			return `{"id": Int(1), "id": Int(2)}` + "\n"
+		}
+		if query == "SELECT * FROM empty" {
+			return "No rows\n"
+		}
+		return "Error: no such table\n"
+	}, WithStrictParsing(true))
+
+	var buf bytes.Buffer
+	var serverErr *ServerError
+	if err := client.ExecuteNDJSON("SELECT * FROM missing", &buf); !errors.As(err, &serverErr) {
+		t.Errorf("err = %v, want *ServerError", err)
+	}
+	if err := client.ExecuteNDJSON("SELECT * FROM empty", &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("empty result: err = %v, output %q", err, buf.String())
+	}
+
+	var parseErr *ParseError
+	if err := client.ExecuteNDJSON("SELECT * FROM dup", &buf); !errors.As(err, &parseErr) {
+		t.Errorf("err = %v, want *ParseError", err)
+	}
+}
+
+// firstWrite records what is written and closes written on the first write.
+type firstWrite struct {
+	bytes.Buffer
+	once    sync.Once
+	written chan struct{}
+}
+
+func (w *firstWrite) Write(p []byte) (int, error) {
+	defer w.once.Do(func() { close(w.written) })
+	return w.Buffer.Write(p)
+}
+
+func TestExecuteNDJSONStreams(t *testing.T) {
+	w := &firstWrite{written: make(chan struct{})}
+	var conn net.Conn
+	s := &mockServer{
+		handshake: func(c net.Conn, reader *bufio.Reader) bool {
+			conn = c
+			return defaultHandshake(c, reader)
+		},
+		handle: func(query string) string {
+			fmt.Fprint(conn, `{"id": Int(1)}`+"\n")
+			select {
+			case <-w.written:
+				return `{"id": Int(2)}` + "\n"
+			case <-time.After(5 * time.Second):
+				return "Error: first row was not written before the response ended\n"
+			}
+		},
+	}
+	s.start(t)
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.ExecuteNDJSON("SELECT * FROM users", w); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"id\":1}\n{\"id\":2}\n"; w.String() != want {
+		t.Errorf("got %q, want %q", w.String(), want)
+	}
+}
+
+func TestExecuteNDJSONMaxResponseSize(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "SELECT * FROM small" {
+			return `{"id": Int(1)}` + "\n"
+		}
+		return strings.Repeat(`{"id": Int(1)}`+"\n", 100)
+	}, WithMaxResponseSize(256))
+
+	var buf bytes.Buffer
+	var tooLarge *ResponseTooLargeError
+	if err := client.ExecuteNDJSON("SELECT * FROM big", &buf); !errors.As(err, &tooLarge) {
+		t.Errorf("err = %v, want *ResponseTooLargeError", err)
+	}
+	if err := client.ExecuteNDJSON("SELECT * FROM small", &buf); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("after an oversized response: err = %v, want ErrNotConnected", err)
+	}
+}
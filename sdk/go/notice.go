@@ -0,0 +1,71 @@
+package poubelle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notice is an asynchronous message pushed by the server outside the
+// response to a statement: either a NOTICE line, or a NOTIFY line delivered
+// on a channel subscribed with Listen.
+type Notice struct {
+	// Channel is the channel a notification was sent on, or empty for a
+	// plain notice.
+	Channel string
+	Message string
+}
+
+// WithNoticeHandler sets fn to receive notices and notifications interleaved
+// with query responses. They are removed from the response either way. fn
+// runs on the querying goroutine while the connection is held, so it must
+// not use the client.
+func WithNoticeHandler(fn func(Notice)) Option {
+	return func(c *Client) {
+		c.noticeHandler = fn
+	}
+}
+
+// Listen subscribes the connection to channel with LISTEN. Notifications
+// arrive as "NOTIFY channel: payload" lines, which are delivered to the
+// notice handler whenever a response is read; there is no background
+// reader, so they are seen on the next operation.
+func (c *Client) Listen(channel string) error {
+	if !isIdentifier(channel) {
+		return fmt.Errorf("invalid channel name %q", channel)
+	}
+	return c.ExecuteDDL("LISTEN " + channel)
+}
+
+// extractNotices removes notice lines from a response and passes them to
+// the notice handler.
+func (c *Client) extractNotices(result string) string {
+	if !strings.Contains(result, "NOTICE: ") && !strings.Contains(result, "NOTIFY ") {
+		return result
+	}
+
+	var kept []string
+	for _, line := range strings.SplitAfter(result, "\n") {
+		notice, ok := parseNotice(strings.TrimSpace(line))
+		if !ok {
+			kept = append(kept, line)
+			continue
+		}
+		if c.noticeHandler != nil {
+			c.noticeHandler(notice)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+func parseNotice(line string) (Notice, bool) {
+	if msg, ok := strings.CutPrefix(line, "NOTICE: "); ok {
+		return Notice{Message: msg}, true
+	}
+	if rest, ok := strings.CutPrefix(line, "NOTIFY "); ok {
+		channel, payload, ok := strings.Cut(rest, ": ")
+		if ok && isIdentifier(channel) {
+			return Notice{Channel: channel, Message: payload}, true
+		}
+	}
+	return Notice{}, false
+}
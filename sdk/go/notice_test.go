@@ -0,0 +1,74 @@
+package poubelle
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNoticesInterleavedWithResults(t *testing.T) {
+	var mu sync.Mutex
+	var notices []Notice
+	handler := func(n Notice) {
+		mu.Lock()
+		defer mu.Unlock()
+		notices = append(notices, n)
+	}
+
+	client := connectMock(t, func(query string) string {
+		switch query {
+		case "LISTEN jobs":
+			return "OK\n"
+		case "SELECT * FROM users":
+			return "NOTICE: table users is large\n" +
+				`{"id": Int(1)}` + "\n" +
+				"NOTIFY jobs: job 7 done\n" +
+				`{"id": Int(2)}` + "\n"
+		}
+		return "Error: unexpected statement\n"
+	}, WithNoticeHandler(handler))
+
+	if err := client.Listen("jobs"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := client.Execute("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Row{{"id": int64(1)}, {"id": int64(2)}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	want := []Notice{
+		{Message: "table users is large"},
+		{Channel: "jobs", Message: "job 7 done"},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(notices, want) {
+		t.Errorf("notices = %+v, want %+v", notices, want)
+	}
+}
+
+func TestNoticesStrippedWithoutHandler(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "NOTICE: deprecated syntax\nOK\n"
+	})
+
+	result, err := client.Query("CREATE TABLE t (id INT)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "OK" {
+		t.Errorf("Query() = %q, want OK", result)
+	}
+}
+
+func TestListenRejectsInvalidChannel(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "OK\n" })
+
+	if err := client.Listen("jobs; DROP TABLE users"); err == nil {
+		t.Error("expected invalid channel to be rejected")
+	}
+}
@@ -0,0 +1,186 @@
+package poubelle
+
+import (
+	"io"
+	"time"
+)
+
+// Logger receives diagnostic messages from the client. *log.Logger
+// satisfies it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Option configures a Client created with NewClient.
+type Option func(*Client)
+
+// WithMaxResponseSize caps the number of bytes read for a single query
+// response. A response over the limit fails with *ResponseTooLargeError and
+// closes the connection, since the rest of it is still in flight. Zero, the
+// default, means unlimited.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = n
+	}
+}
+
+// WithMaxQueryLength rejects statements longer than n bytes with
+// ErrQueryTooLong before they are sent, as a guard against accidentally
+// building a giant statement, such as an unbounded IN list. The length is
+// that of the statement after arguments are bound. Zero, the default, means
+// unlimited.
+func WithMaxQueryLength(n int) Option {
+	return func(c *Client) {
+		c.maxQueryLength = n
+	}
+}
+
+// WithOnConnect runs the given statements, in order, right after every
+// successful handshake. Use it for session settings. If any statement fails
+// or the server answers it with an error, Connect fails.
+func WithOnConnect(stmts ...string) Option {
+	return func(c *Client) {
+		c.onConnect = append(c.onConnect, stmts...)
+	}
+}
+
+// WithLogger sets the logger used for warnings, such as falling back to a
+// plaintext password exchange.
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithWireTrace writes a timestamped hex dump of every chunk sent to and
+// received from the server to w. It is meant for diagnosing protocol
+// problems and has no cost when not set.
+func WithWireTrace(w io.Writer) Option {
+	return func(c *Client) {
+		c.wireTrace = w
+	}
+}
+
+// WithCompression asks the server to gzip its responses after connecting.
+// Servers without compression support are used uncompressed.
+func WithCompression(enabled bool) Option {
+	return func(c *Client) {
+		c.compression = enabled
+	}
+}
+
+// WithAutoReconnect re-establishes the connection and retries a statement
+// once when writing it fails because the server closed the connection.
+func WithAutoReconnect(enabled bool) Option {
+	return func(c *Client) {
+		c.autoReconnect = enabled
+	}
+}
+
+// WithAutoTransaction makes every write sent outside a transaction, as
+// classified by ClassifyStatement, run between BEGIN and COMMIT, and be
+// rolled back if the server rejects it. Each such write costs two extra
+// round trips. Reads, DDL, statements inside a Tx, and those sent with
+// ExecuteAsync or ExecuteBatch are sent as they are.
+func WithAutoTransaction(enabled bool) Option {
+	return func(c *Client) {
+		c.autoTransaction = enabled
+	}
+}
+
+// WithTimeout sets the dial timeout, the read timeout for each handshake step
+// and the read timeout for each query response to d. WithDialTimeout and
+// WithReadTimeout take precedence over it regardless of the order the options
+// are given in.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithDialTimeout bounds how long establishing the TCP connection may take.
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.dialTimeout = d
+	}
+}
+
+// WithReadTimeout bounds how long the client waits for each handshake prompt
+// and for each query response. A query that times out closes the
+// connection, since its response may still arrive.
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.readTimeout = d
+	}
+}
+
+// WithHandshakeTimeout bounds the whole authentication exchange, from the
+// first prompt to the success banner, to d. If the server has not finished
+// by then, Connect closes the connection and returns ErrHandshakeTimeout.
+// Unlike WithReadTimeout, it also catches a server that keeps sending data
+// without ever completing the handshake.
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.handshakeTimeout = d
+	}
+}
+
+// WithWriteBufferSize sets the size of the buffer statements are written
+// through. The buffer is flushed after every request. The default is 4096
+// bytes; statements larger than the buffer are written in several chunks.
+func WithWriteBufferSize(n int) Option {
+	return func(c *Client) {
+		c.writeBufferSize = n
+	}
+}
+
+// WithSlowQueryThreshold calls fn with the statement and its duration for
+// every query that takes longer than d, measured from just before the
+// statement is written until its whole response has been read. fn runs on
+// the querying goroutine while the connection is held, so it must not use
+// the client.
+func WithSlowQueryThreshold(d time.Duration, fn func(sql string, d time.Duration)) Option {
+	return func(c *Client) {
+		c.slowQueryThreshold = d
+		c.slowQuery = fn
+	}
+}
+
+// WithStatementTerminator sets the string appended to every statement sent
+// with Query and the methods built on it, for servers configured to expect
+// something like ";\n". It is not added to a statement that already ends
+// with it. The default is "\n".
+func WithStatementTerminator(term string) Option {
+	return func(c *Client) {
+		c.terminator = term
+	}
+}
+
+// WithReprobeOnReconnect makes reconnects try the hosts of a multi-host
+// connection string from the first one again, instead of starting with the
+// host last connected to. Use it to return to a preferred primary.
+func WithReprobeOnReconnect(enabled bool) Option {
+	return func(c *Client) {
+		c.reprobe = enabled
+	}
+}
+
+// WithStrictParsing makes a row that names the same column twice, or a
+// record cut off before its closing brace, fail with a *ParseError. By
+// default the last duplicate value wins, truncated records are dropped, and
+// a warning is logged.
+func WithStrictParsing(enabled bool) Option {
+	return func(c *Client) {
+		c.strictParsing = enabled
+	}
+}
+
+// WithGracefulClose controls whether Close reads the server's goodbye after
+// sending exit. Waiting for it, the default, lets the server finish writing
+// before the socket closes, so it does not log a connection reset. Disable
+// it to close immediately.
+func WithGracefulClose(enabled bool) Option {
+	return func(c *Client) {
+		c.immediateClose = !enabled
+	}
+}
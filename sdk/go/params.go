@@ -0,0 +1,114 @@
+package poubelle
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// QueryParams replaces each ? placeholder in sql with the matching argument,
+// escaped as a Poubelle literal, and runs the resulting statement.
+//
+// The placeholder positions of recently used statements are cached; see
+// WithStatementCacheSize.
+//
+// Supported argument types are nil, the integer types, string and []byte.
+// Text is quoted as set with WithQuoteStyle.
+// Poubelle has no blob type or blob literal syntax, so a []byte is sent as a
+// TEXT literal holding the bytes verbatim; it must be valid UTF-8 and is
+// subject to the same restrictions as a string.
+func (c *Client) QueryParams(sql string, args ...interface{}) (string, error) {
+	stmt, err := c.stmtCache.get(sql).bind(args, c.quoteStyle)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Query(stmt)
+}
+
+// bindArgs binds args to the placeholders of sql as QueryParams does.
+// Without args, sql is returned as is, so a literal ? needs no escaping.
+func (c *Client) bindArgs(sql string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return sql, nil
+	}
+	return c.stmtCache.get(sql).bind(args, c.quoteStyle)
+}
+
+// queryArgs binds args into sql and runs it without the default format, for
+// methods that parse the debug format.
+func (c *Client) queryArgs(sql string, args []interface{}) (string, error) {
+	stmt, err := c.bindArgs(sql, args)
+	if err != nil {
+		return "", err
+	}
+	return c.do(stmt)
+}
+
+// queryJSON is queryArgs for statements run with FORMAT JSON.
+func (c *Client) queryJSON(sql string, args []interface{}) (string, error) {
+	if err := c.checkFeature(featureJSON); err != nil {
+		return "", err
+	}
+	stmt, err := c.bindArgs(sql, args)
+	if err != nil {
+		return "", err
+	}
+	return c.do(withJSONFormat(stmt))
+}
+
+func bindParams(sql string, args []interface{}) (string, error) {
+	return compileTemplate(sql).bind(args, QuoteVerbatim)
+}
+
+// formatLiteral writes arg as a Poubelle literal, quoting text with style.
+func formatLiteral(arg interface{}, style QuoteStyle) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint:
+		return formatUint(uint64(v))
+	case uint64:
+		return formatUint(v)
+	case string:
+		return style.quote(v)
+	case []byte:
+		if !utf8.Valid(v) {
+			return "", fmt.Errorf("byte slice is not valid UTF-8 and cannot be stored as TEXT")
+		}
+		return style.quote(string(v))
+	default:
+		return "", fmt.Errorf("unsupported parameter type %T", arg)
+	}
+}
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isIdentifier(s string) bool {
+	return identifierRe.MatchString(s)
+}
+
+func formatUint(v uint64) (string, error) {
+	if v > math.MaxInt64 {
+		return "", fmt.Errorf("value %d overflows the server's 64-bit INT", v)
+	}
+	return strconv.FormatUint(v, 10), nil
+}
@@ -0,0 +1,153 @@
+package poubelle
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBindParams(t *testing.T) {
+	tests := []struct {
+		sql  string
+		args []interface{}
+		want string
+	}{
+		{"SELECT * FROM t WHERE id = ?", []interface{}{42}, "SELECT * FROM t WHERE id = 42"},
+		{"INSERT INTO t (a, b) VALUES (?, ?)", []interface{}{"x", nil}, "INSERT INTO t (a, b) VALUES ('x', NULL)"},
+		{"SELECT * FROM t WHERE a = '?' AND b = ?", []interface{}{int64(-1)}, "SELECT * FROM t WHERE a = '?' AND b = -1"},
+		{"INSERT INTO t (data) VALUES (?)", []interface{}{[]byte("raw")}, "INSERT INTO t (data) VALUES ('raw')"},
+	}
+
+	for _, tt := range tests {
+		got, err := bindParams(tt.sql, tt.args)
+		if err != nil {
+			t.Errorf("bindParams(%q) error: %v", tt.sql, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("bindParams(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestBindParamsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		args []interface{}
+	}{
+		{"missing argument", "VALUES (?, ?)", []interface{}{1}},
+		{"extra argument", "VALUES (?)", []interface{}{1, 2}},
+		{"quote in text", "VALUES (?)", []interface{}{"it's"}},
+		{"newline in text", "VALUES (?)", []interface{}{"a\nb"}},
+		{"invalid utf-8 bytes", "VALUES (?)", []interface{}{[]byte{0xff, 0xfe}}},
+		{"quote in bytes", "VALUES (?)", []interface{}{[]byte("a'b")}},
+		{"unsupported type", "VALUES (?)", []interface{}{struct{}{}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := bindParams(tt.sql, tt.args); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestQueryParamsBlobRoundTrip(t *testing.T) {
+	table := &textTable{}
+	client := connectMock(t, table.handle)
+
+	blob := []byte{0x62, 0x6c, 0x6f, 0x62, 0x20, 0x7e, 0x30, 0x31}
+	if _, err := client.QueryParams("INSERT INTO blobs (data) VALUES (?)", blob); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := client.Execute("SELECT * FROM blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+
+	got, ok := rows[0]["data"].(string)
+	if !ok || !bytes.Equal([]byte(got), blob) {
+		t.Errorf("data = %q, want %q", rows[0]["data"], blob)
+	}
+}
+
+func TestExecuteArgs(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if strings.HasSuffix(query, "FORMAT JSON") {
+			return `[{"id": 1}]` + "\n"
+		}
+		if strings.HasSuffix(query, "FORMAT TABLE") {
+			return "id\n--\n1\n"
+		}
+		return "{\"id\": Int(1)}\n"
+	})
+
+	const sql = "SELECT * FROM users WHERE name = ?"
+	const bound = "SELECT * FROM users WHERE name = 'Alice'"
+	var dest []struct{ ID int }
+
+	tests := []struct {
+		name string
+		run  func(args ...interface{}) error
+		want string
+	}{
+		{"Execute", func(args ...interface{}) error { _, err := client.Execute(sql, args...); return err }, bound},
+		{"ExecuteDDL", func(args ...interface{}) error { return client.ExecuteDDL(sql, args...) }, bound},
+		{"ExecuteJSON", func(args ...interface{}) error { _, err := client.ExecuteJSON(sql, args...); return err }, bound + " FORMAT JSON"},
+		{"ExecuteJSONInto", func(args ...interface{}) error { return client.ExecuteJSONInto(sql, &dest, args...) }, bound + " FORMAT JSON"},
+		{"ExecuteJSONBytes", func(args ...interface{}) error { _, err := client.ExecuteJSONBytes(sql, args...); return err }, bound + " FORMAT JSON"},
+		{"ExecuteTyped", func(args ...interface{}) error { _, err := client.ExecuteTyped(sql, args...); return err }, bound},
+		{"ExecuteMulti", func(args ...interface{}) error { _, err := client.ExecuteMulti(sql, args...); return err }, bound},
+		{"ExecuteTable", func(args ...interface{}) error { _, err := client.ExecuteTable(sql, args...); return err }, bound + " FORMAT TABLE"},
+		{"ExecuteColumnar", func(args ...interface{}) error { _, err := client.ExecuteColumnar(sql, args...); return err }, bound},
+		{"QueryScan", func(args ...interface{}) error { return client.QueryScan(sql, func(Row) {}, args...) }, bound},
+		{"QueryRow", func(args ...interface{}) error { _, err := client.QueryRow(sql, args...); return err }, bound},
+		{"QueryScalar", func(args ...interface{}) error { _, err := client.QueryScalar(sql, args...); return err }, bound},
+	}
+
+	for _, tt := range tests {
+		log = queryLog{}
+		if err := tt.run("Alice"); err != nil {
+			t.Errorf("%s with args: %v", tt.name, err)
+		}
+		// Without args the statement is sent as is, placeholder included.
+		if err := tt.run(); err != nil {
+			t.Errorf("%s without args: %v", tt.name, err)
+		}
+		if err := tt.run("Alice", "Bob"); err == nil {
+			t.Errorf("%s with too many args: expected error", tt.name)
+		}
+
+		want := []string{tt.want, strings.Replace(tt.want, "'Alice'", "?", 1)}
+		if got := log.all(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("%s sent %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if strings.Contains(query, "999") {
+			return "No rows\n"
+		}
+		return "{\"id\": Int(7), \"name\": Text(\"Alice\")}\n"
+	})
+
+	row, err := client.QueryRow("SELECT * FROM users WHERE id = ?", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row["name"] != "Alice" {
+		t.Errorf("row = %v", row)
+	}
+	if _, err := client.QueryRow("SELECT * FROM users WHERE id = ?", 999); !errors.Is(err, ErrNoRows) {
+		t.Errorf("err = %v, want ErrNoRows", err)
+	}
+}
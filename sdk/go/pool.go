@@ -0,0 +1,370 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPoolTimeout is returned by Pool.Acquire when no connection became
+// available before the context was done.
+var ErrPoolTimeout = errors.New("timed out waiting for a pool connection")
+
+// ErrPoolClosed is returned by Pool.Acquire after Close.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// Pool hands out authenticated clients, keeping at most a fixed number of
+// connections open at once.
+type Pool struct {
+	dsn      string
+	opts     []Option
+	maxConns int
+	strategy PoolStrategy
+
+	maxIdleConns    int
+	connMaxIdleTime time.Duration
+	clock           Clock
+
+	sem chan struct{}
+
+	// stopReaper is closed by Close to stop the idle reaper, which closes
+	// reaperDone when it returns.
+	stopReaper chan struct{}
+	reaperDone chan struct{}
+
+	mu           sync.Mutex
+	idle         []idleConn
+	inUse        int
+	waitCount    int64
+	waitDuration time.Duration
+	closed       bool
+}
+
+// idleConn is a connection waiting in the pool, with the time it was
+// released.
+type idleConn struct {
+	client *Client
+	since  time.Time
+}
+
+// PoolStats is a snapshot of pool usage.
+type PoolStats struct {
+	InUse        int
+	Idle         int
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// PoolStrategy selects which idle connection Acquire reuses.
+type PoolStrategy int
+
+const (
+	// LIFO reuses the most recently released connection, keeping a small
+	// set of connections warm. It is the default.
+	LIFO PoolStrategy = iota
+	// FIFO reuses the connection that has been idle longest, spreading
+	// load across all connections.
+	FIFO
+)
+
+// PoolOption configures a Pool created with NewPool.
+type PoolOption func(*Pool)
+
+// WithMaxConns caps the number of open connections. The default is 10.
+func WithMaxConns(n int) PoolOption {
+	return func(p *Pool) {
+		p.maxConns = n
+	}
+}
+
+// WithPoolStrategy sets the order in which idle connections are reused.
+func WithPoolStrategy(s PoolStrategy) PoolOption {
+	return func(p *Pool) {
+		p.strategy = s
+	}
+}
+
+// WithMaxIdleConns caps the number of idle connections the pool keeps. A
+// connection released while n are already idle is closed. The default, 0,
+// keeps every released connection, up to the pool size.
+func WithMaxIdleConns(n int) PoolOption {
+	return func(p *Pool) {
+		p.maxIdleConns = n
+	}
+}
+
+// WithConnMaxIdleTime makes a background reaper close connections that
+// have been idle for d or longer. Connections in use are never touched.
+// The default, 0, keeps idle connections open indefinitely.
+func WithConnMaxIdleTime(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.connMaxIdleTime = d
+	}
+}
+
+// WithPoolClock makes the pool read time from clock for idle times
+// instead of the system clock, as WithClock does for a client.
+func WithPoolClock(clock Clock) PoolOption {
+	return func(p *Pool) {
+		if clock == nil {
+			clock = realClock{}
+		}
+		p.clock = clock
+	}
+}
+
+// WithClientOptions sets the options every pooled client is created with.
+func WithClientOptions(opts ...Option) PoolOption {
+	return func(p *Pool) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// NewPool creates a pool for the given connection string. Connections are
+// opened lazily by Acquire.
+func NewPool(connectionString string, opts ...PoolOption) (*Pool, error) {
+	if _, err := parseConnectionString(connectionString); err != nil {
+		return nil, err
+	}
+
+	p := &Pool{dsn: connectionString, maxConns: 10, clock: realClock{}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.maxConns <= 0 {
+		return nil, fmt.Errorf("pool size must be positive, got %d", p.maxConns)
+	}
+	if p.maxIdleConns < 0 {
+		return nil, fmt.Errorf("max idle connections must not be negative, got %d", p.maxIdleConns)
+	}
+	p.sem = make(chan struct{}, p.maxConns)
+
+	if p.connMaxIdleTime > 0 {
+		p.stopReaper = make(chan struct{})
+		p.reaperDone = make(chan struct{})
+		go p.reap()
+	}
+	return p, nil
+}
+
+// Acquire returns a connected client and a function that gives it back to
+// the pool. If every connection is in use, Acquire waits until one is
+// released or ctx is done, in which case it returns ErrPoolTimeout. The
+// release function must be called exactly once; a client whose connection
+// was closed is discarded rather than reused.
+func (p *Pool) Acquire(ctx context.Context) (*Client, func(), error) {
+	if err := p.reserve(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	client, err := p.take(ctx)
+	if err != nil {
+		p.unreserve()
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { p.put(client) })
+	}
+	return client, release, nil
+}
+
+// reserve claims one of the pool's connection slots.
+func (p *Pool) reserve(ctx context.Context) error {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.sem <- struct{}{}:
+	default:
+		start := time.Now()
+		select {
+		case p.sem <- struct{}{}:
+			p.mu.Lock()
+			p.waitCount++
+			p.waitDuration += time.Since(start)
+			p.mu.Unlock()
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.waitCount++
+			p.waitDuration += time.Since(start)
+			p.mu.Unlock()
+			return fmt.Errorf("%w: %w", ErrPoolTimeout, ctx.Err())
+		}
+	}
+
+	p.mu.Lock()
+	p.inUse++
+	p.mu.Unlock()
+	return nil
+}
+
+// unreserve gives back a slot claimed by reserve that no client took.
+func (p *Pool) unreserve() {
+	p.mu.Lock()
+	p.inUse--
+	p.mu.Unlock()
+	<-p.sem
+}
+
+// take returns an idle client, or opens a new one if there is none.
+func (p *Pool) take(ctx context.Context) (*Client, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		var client *Client
+		if p.strategy == FIFO {
+			client = p.idle[0].client
+			p.idle = p.idle[1:]
+		} else {
+			client = p.idle[n-1].client
+			p.idle = p.idle[:n-1]
+		}
+		p.mu.Unlock()
+		return client, nil
+	}
+	p.mu.Unlock()
+
+	return p.open(ctx)
+}
+
+// open creates and connects a new client.
+func (p *Pool) open(ctx context.Context) (*Client, error) {
+	client, err := NewClient(p.dsn, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.WithContext(ctx).Connect(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Warmup opens and authenticates connections until the pool holds n, idle
+// or in use, so that the first Acquire calls do not pay for connecting.
+// It returns an error if fewer than n could be opened before ctx is done,
+// keeping the ones that were.
+func (p *Pool) Warmup(ctx context.Context, n int) error {
+	if n > p.maxConns {
+		return fmt.Errorf("cannot warm up %d connections, pool size is %d", n, p.maxConns)
+	}
+	if p.maxIdleConns > 0 && n > p.maxIdleConns {
+		return fmt.Errorf("cannot warm up %d connections, at most %d are kept idle", n, p.maxIdleConns)
+	}
+
+	for {
+		p.mu.Lock()
+		open := len(p.idle) + p.inUse
+		p.mu.Unlock()
+		if open >= n {
+			return nil
+		}
+
+		if err := p.reserve(ctx); err != nil {
+			return fmt.Errorf("warmed up %d of %d connections: %w", open, n, err)
+		}
+		client, err := p.open(ctx)
+		if err != nil {
+			p.unreserve()
+			return fmt.Errorf("warmed up %d of %d connections: %w", open, n, err)
+		}
+		p.put(client)
+	}
+}
+
+func (p *Pool) put(client *Client) {
+	// connected takes client.mu, which a goroutine still using the client
+	// may hold, so it is read before p.mu to keep the pool responsive.
+	alive := client.connected()
+
+	p.mu.Lock()
+	p.inUse--
+	reuse := !p.closed && alive && (p.maxIdleConns == 0 || len(p.idle) < p.maxIdleConns)
+	if reuse {
+		p.idle = append(p.idle, idleConn{client: client, since: p.clock.Now()})
+	}
+	p.mu.Unlock()
+
+	if !reuse {
+		client.Close()
+	}
+	<-p.sem
+}
+
+// Stats returns current pool usage.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return PoolStats{
+		InUse:        p.inUse,
+		Idle:         len(p.idle),
+		WaitCount:    p.waitCount,
+		WaitDuration: p.waitDuration,
+	}
+}
+
+// reap closes connections idle for connMaxIdleTime or longer, waking when
+// the oldest idle connection is due, until Close.
+func (p *Pool) reap() {
+	defer close(p.reaperDone)
+
+	for {
+		p.mu.Lock()
+		now := p.clock.Now()
+		wait := p.connMaxIdleTime
+		var expired []*Client
+		kept := p.idle[:0]
+		for _, ic := range p.idle {
+			if age := now.Sub(ic.since); age >= p.connMaxIdleTime {
+				expired = append(expired, ic.client)
+			} else {
+				kept = append(kept, ic)
+				wait = min(wait, p.connMaxIdleTime-age)
+			}
+		}
+		clear(p.idle[len(kept):])
+		p.idle = kept
+		p.mu.Unlock()
+
+		for _, client := range expired {
+			client.Close()
+		}
+
+		select {
+		case <-p.clock.After(wait):
+		case <-p.stopReaper:
+			return
+		}
+	}
+}
+
+// Close closes all idle connections and stops the idle reaper. Clients
+// still checked out are closed when they are released.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	wasClosed := p.closed
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	if p.stopReaper != nil && !wasClosed {
+		close(p.stopReaper)
+		<-p.reaperDone
+	}
+
+	var firstErr error
+	for _, ic := range idle {
+		if err := ic.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,352 @@
+package poubelle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newMockPool(t *testing.T, opts ...PoolOption) *Pool {
+	t.Helper()
+
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+	pool, err := NewPool(s.dsn(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestPoolAcquireTimeout(t *testing.T) {
+	pool := newMockPool(t, WithMaxConns(1))
+
+	client, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Fatalf("Query() = %q, %v", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := pool.Acquire(ctx); !errors.Is(err, ErrPoolTimeout) {
+		t.Fatalf("Acquire() on exhausted pool error = %v, want ErrPoolTimeout", err)
+	}
+
+	stats := pool.Stats()
+	if stats.InUse != 1 || stats.Idle != 0 || stats.WaitCount != 1 || stats.WaitDuration < 50*time.Millisecond {
+		t.Errorf("stats after timeout = %+v", stats)
+	}
+
+	release()
+	again, releaseAgain, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != client {
+		t.Error("expected the released connection to be reused")
+	}
+	releaseAgain()
+
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Idle != 1 {
+		t.Errorf("stats after release = %+v", stats)
+	}
+}
+
+func TestPoolWaiterGetsReleasedConnection(t *testing.T) {
+	pool := newMockPool(t, WithMaxConns(1))
+
+	_, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(30*time.Millisecond, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, releaseWaiter, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("waiting Acquire() error = %v", err)
+	}
+	releaseWaiter()
+
+	if stats := pool.Stats(); stats.WaitCount != 1 || stats.WaitDuration <= 0 {
+		t.Errorf("stats = %+v, want one recorded wait", stats)
+	}
+}
+
+func TestPoolDiscardsBrokenConnection(t *testing.T) {
+	pool := newMockPool(t, WithMaxConns(1))
+
+	client, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	release()
+
+	if stats := pool.Stats(); stats.Idle != 0 {
+		t.Errorf("broken connection was returned to the idle set: %+v", stats)
+	}
+}
+
+func TestPoolReleaseOfBusyClient(t *testing.T) {
+	pool := newMockPool(t, WithMaxConns(2))
+
+	client, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the client's lock as an open transaction or a query on another
+	// goroutine would.
+	client.mu.Lock()
+	released := make(chan struct{})
+	go func() {
+		release()
+		close(released)
+	}()
+
+	stats := make(chan PoolStats, 1)
+	go func() { stats <- pool.Stats() }()
+	select {
+	case <-stats:
+	case <-time.After(time.Second):
+		t.Fatal("Stats() blocked while a busy client was being released")
+	}
+
+	client.mu.Unlock()
+	<-released
+	if s := pool.Stats(); s.InUse != 0 || s.Idle != 1 {
+		t.Errorf("stats after release = %+v", s)
+	}
+}
+
+func TestPoolStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []PoolOption
+		wantLast bool
+	}{
+		{"default", nil, true},
+		{"lifo", []PoolOption{WithPoolStrategy(LIFO)}, true},
+		{"fifo", []PoolOption{WithPoolStrategy(FIFO)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newMockPool(t, tt.opts...)
+			ctx := context.Background()
+
+			var clients []*Client
+			var releases []func()
+			for i := 0; i < 3; i++ {
+				client, release, err := pool.Acquire(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				clients = append(clients, client)
+				releases = append(releases, release)
+			}
+			for _, release := range releases {
+				release()
+			}
+
+			want := clients[0]
+			if tt.wantLast {
+				want = clients[2]
+			}
+			client, release, err := pool.Acquire(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+			if client != want {
+				t.Errorf("Acquire() returned the wrong idle client")
+			}
+		})
+	}
+}
+
+func TestPoolWarmup(t *testing.T) {
+	var accepted atomic.Int32
+	s := &mockServer{
+		handle: func(query string) string { return "ok\n" },
+		handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+			if accepted.Add(1) > 3 {
+				return false
+			}
+			return defaultHandshake(conn, reader)
+		},
+	}
+	s.start(t)
+	pool, err := NewPool(s.dsn(), WithMaxConns(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if err := pool.Warmup(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if stats := pool.Stats(); stats.Idle != 3 || stats.InUse != 0 {
+		t.Errorf("stats after Warmup(3) = %+v, want 3 idle", stats)
+	}
+	if err := pool.Warmup(context.Background(), 2); err != nil || accepted.Load() != 3 {
+		t.Errorf("Warmup(2) on a warm pool = %v after %d connections, want no new ones", err, accepted.Load())
+	}
+
+	client, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Errorf("Query() on warmed connection = %q, %v", result, err)
+	}
+	release()
+	if accepted.Load() != 3 {
+		t.Errorf("Acquire opened a new connection, want a warmed one reused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.Warmup(ctx, 5); err == nil || !strings.Contains(err.Error(), "warmed up 3 of 5") {
+		t.Errorf("Warmup(5) with a failing server error = %v", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 3 || stats.InUse != 0 {
+		t.Errorf("stats after failed Warmup = %+v, want the 3 warm connections kept", stats)
+	}
+	if err := pool.Warmup(ctx, 6); err == nil {
+		t.Error("Warmup beyond the pool size succeeded")
+	}
+}
+
+// manualClock is a Clock whose After channels fire only once Advance moves
+// it past their deadline, for driving background loops step by step.
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (m *manualClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *manualClock) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	m.waiters = append(m.waiters, manualWaiter{at: m.now.Add(d), ch: ch})
+	return ch
+}
+
+func (m *manualClock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at.After(m.now) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- m.now
+		}
+	}
+	m.waiters = pending
+}
+
+func waitForIdle(t *testing.T, pool *Pool, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Idle != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle connections = %d, want %d", pool.Stats().Idle, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolConnMaxIdleTime(t *testing.T) {
+	clock := newManualClock()
+	pool := newMockPool(t, WithConnMaxIdleTime(time.Minute), WithPoolClock(clock))
+
+	if err := pool.Warmup(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	client, release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(30 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if idle := pool.Stats().Idle; idle != 2 {
+		t.Fatalf("idle = %d before the idle time passed, want 2", idle)
+	}
+
+	clock.Advance(31 * time.Second)
+	waitForIdle(t, pool, 0)
+	if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+		t.Errorf("checked-out client was reaped: %q, %v", result, err)
+	}
+
+	release()
+	if stats := pool.Stats(); stats.Idle != 1 || stats.InUse != 0 {
+		t.Errorf("stats after release = %+v, want 1 idle", stats)
+	}
+	clock.Advance(time.Minute)
+	waitForIdle(t, pool, 0)
+
+	done := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not stop the reaper")
+	}
+}
+
+func TestPoolMaxIdleConns(t *testing.T) {
+	pool := newMockPool(t, WithMaxIdleConns(1))
+
+	var releases []func()
+	for i := 0; i < 3; i++ {
+		_, release, err := pool.Acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+	for _, release := range releases {
+		release()
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.InUse != 0 {
+		t.Errorf("stats = %+v, want 1 idle connection kept", stats)
+	}
+	if err := pool.Warmup(context.Background(), 2); err == nil {
+		t.Error("Warmup beyond the idle limit succeeded")
+	}
+}
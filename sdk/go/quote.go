@@ -0,0 +1,72 @@
+package poubelle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteStyle selects how single quotes inside text values are escaped when
+// the SDK writes a string literal.
+type QuoteStyle int
+
+const (
+	// QuoteVerbatim writes text between single quotes as is and rejects
+	// text containing a quote. It matches the Poubelle server, whose lexer
+	// (read_string in crates/parser/src/lexer.rs) ends a literal at the
+	// next quote and has no escape sequences, so a quote cannot be
+	// represented at all. It is the default.
+	QuoteVerbatim QuoteStyle = iota
+	// QuoteDoubled escapes a quote by doubling it, as standard SQL does:
+	// 'O''Brien'. Backslashes are written as is.
+	QuoteDoubled
+	// QuoteBackslash escapes a quote and a backslash with a backslash, as
+	// MySQL does: 'O\'Brien', 'C:\\dir'.
+	QuoteBackslash
+)
+
+func (s QuoteStyle) String() string {
+	switch s {
+	case QuoteDoubled:
+		return "doubled"
+	case QuoteBackslash:
+		return "backslash"
+	default:
+		return "verbatim"
+	}
+}
+
+// WithQuoteStyle sets how text values are escaped by QueryParams, the
+// methods that take args, QuoteString, InsertStruct, BuildWhere,
+// ChangePassword and Dump. Only change it for a server whose lexer
+// understands the chosen escapes; the default, QuoteVerbatim, is what
+// Poubelle accepts.
+func WithQuoteStyle(style QuoteStyle) Option {
+	return func(c *Client) {
+		c.quoteStyle = style
+	}
+}
+
+// QuoteString returns s as a string literal in the client's quote style,
+// or an error if s cannot be represented.
+func (c *Client) QuoteString(s string) (string, error) {
+	return c.quoteStyle.quote(s)
+}
+
+// quote wraps text in single quotes, escaping it for the style. A statement
+// ends at the end of the line in every style, so text with a line break is
+// always rejected.
+func (s QuoteStyle) quote(text string) (string, error) {
+	if strings.ContainsAny(text, "\r\n") {
+		return "", fmt.Errorf("text value contains a line break, which would end the statement")
+	}
+	switch s {
+	case QuoteDoubled:
+		return "'" + strings.ReplaceAll(text, "'", "''") + "'", nil
+	case QuoteBackslash:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(text) + "'", nil
+	}
+	if strings.ContainsRune(text, '\'') {
+		return "", fmt.Errorf("text value contains a single quote, which the server cannot escape")
+	}
+	return "'" + text + "'", nil
+}
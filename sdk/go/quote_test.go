@@ -0,0 +1,67 @@
+package poubelle
+
+import "testing"
+
+func TestQuoteStyles(t *testing.T) {
+	const text = `O'Brien\'s C:\dir`
+	tests := []struct {
+		style QuoteStyle
+		want  string
+	}{
+		{QuoteDoubled, `'O''Brien\''s C:\dir'`},
+		{QuoteBackslash, `'O\'Brien\\\'s C:\\dir'`},
+	}
+	for _, tt := range tests {
+		got, err := tt.style.quote(text)
+		if err != nil {
+			t.Errorf("%v: %v", tt.style, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v: quote(%q) = %s, want %s", tt.style, text, got, tt.want)
+		}
+	}
+
+	if _, err := QuoteVerbatim.quote(text); err == nil {
+		t.Error("verbatim: expected a quote to be rejected")
+	}
+	if got, err := QuoteVerbatim.quote(`C:\dir`); err != nil || got != `'C:\dir'` {
+		t.Errorf("verbatim: quote(backslash) = %s, %v", got, err)
+	}
+	for _, style := range []QuoteStyle{QuoteVerbatim, QuoteDoubled, QuoteBackslash} {
+		if _, err := style.quote("a\nb"); err == nil {
+			t.Errorf("%v: expected a line break to be rejected", style)
+		}
+	}
+}
+
+func TestWithQuoteStyle(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "Row inserted\n"
+	}, WithQuoteStyle(QuoteBackslash))
+
+	if _, err := client.QueryParams("INSERT INTO t (s) VALUES (?)", `it's a \ test`); err != nil {
+		t.Fatal(err)
+	}
+	type note struct {
+		Text string `poubelle:"text"`
+	}
+	if _, err := client.InsertStruct("notes", note{Text: "don't"}); err != nil {
+		t.Fatal(err)
+	}
+	where, err := client.BuildWhere(map[string]interface{}{"name": "O'Brien"})
+	if err != nil || where != `WHERE name = 'O\'Brien'` {
+		t.Errorf("BuildWhere() = %q, %v", where, err)
+	}
+	if lit, err := client.QuoteString(`a'b`); err != nil || lit != `'a\'b'` {
+		t.Errorf("QuoteString() = %q, %v", lit, err)
+	}
+
+	want := []string{`INSERT INTO t (s) VALUES ('it\'s a \\ test')`, `INSERT INTO notes (text) VALUES ('don\'t')`}
+	got := log.all()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
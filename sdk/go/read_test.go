@@ -0,0 +1,228 @@
+package poubelle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// readUntilPromptByByte is the previous byte-at-a-time implementation, kept
+// as a reference for tests and benchmarks.
+func readUntilPromptByByte(reader *bufio.Reader, prompt string, limit int64) (string, error) {
+	var buffer bytes.Buffer
+	suffix := []byte(prompt)
+	var n int64
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		n++
+		if limit > 0 && n > limit {
+			return "", &ResponseTooLargeError{Limit: limit}
+		}
+		buffer.WriteByte(b)
+		if bytes.HasSuffix(buffer.Bytes(), suffix) {
+			result := buffer.Bytes()[:buffer.Len()-len(suffix)]
+			return strings.TrimSpace(string(result)), nil
+		}
+	}
+}
+
+func TestReadUntilPrompt(t *testing.T) {
+	inputs := []string{
+		"poubelle> ",
+		"OK\npoubelle> ",
+		"{\"id\": Int(1)}\n{\"id\": Int(2)}\npoubelle> ",
+		"no trailing newline poubelle> ",
+		"first\npoubelle> second\npoubelle> ",
+		"poubelle poubelle> ",
+	}
+
+	for _, in := range inputs {
+		for name, r := range map[string]func() *bufio.Reader{
+			"buffered": func() *bufio.Reader { return bufio.NewReader(strings.NewReader(in)) },
+			"one byte": func() *bufio.Reader { return bufio.NewReader(iotest.OneByteReader(strings.NewReader(in))) },
+			"small":    func() *bufio.Reader { return bufio.NewReaderSize(iotest.HalfReader(strings.NewReader(in)), 16) },
+		} {
+			got, gotRest := readAll(t, r())
+			want, wantRest := readAllByByte(t, bufio.NewReader(strings.NewReader(in)))
+			if got != want || gotRest != wantRest {
+				t.Errorf("%s %q: got %q then %q, want %q then %q", name, in, got, gotRest, want, wantRest)
+			}
+		}
+	}
+}
+
+func readAll(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	result, err := readUntilPrompt(r, "poubelle> ", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := r.ReadString(0)
+	return result, rest
+}
+
+func readAllByByte(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	result, err := readUntilPromptByByte(r, "poubelle> ", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := r.ReadString(0)
+	return result, rest
+}
+
+func TestReadUntilPromptLimit(t *testing.T) {
+	in := "0123456789poubelle> "
+
+	if _, err := readUntilPrompt(bufio.NewReader(strings.NewReader(in)), "poubelle> ", int64(len(in))); err != nil {
+		t.Errorf("response at the limit failed: %v", err)
+	}
+
+	var tooLarge *ResponseTooLargeError
+	for _, r := range []*bufio.Reader{
+		bufio.NewReader(strings.NewReader(in)),
+		bufio.NewReader(iotest.OneByteReader(strings.NewReader(in + strings.Repeat("x", 100)))),
+	} {
+		if _, err := readUntilPrompt(r, "poubelle> ", int64(len(in)-1)); !errors.As(err, &tooLarge) {
+			t.Errorf("error = %v, want *ResponseTooLargeError", err)
+		}
+	}
+}
+
+func TestScanResponse(t *testing.T) {
+	inputs := []string{
+		"poubelle> ",
+		"OK\npoubelle> ",
+		"{\"id\": Int(1)}\n{\"id\": Int(2)}\npoubelle> ",
+		"no trailing newline poubelle> ",
+		"first\npoubelle> second\npoubelle> ",
+		"poubelle poubelle> ",
+	}
+
+	for _, in := range inputs {
+		want, _ := readResponse(bufio.NewReader(strings.NewReader(in)), "poubelle> ", 0)
+		for name, r := range map[string]*bufio.Reader{
+			"buffered": bufio.NewReader(strings.NewReader(in)),
+			"one byte": bufio.NewReader(iotest.OneByteReader(strings.NewReader(in))),
+		} {
+			var got strings.Builder
+			err := scanResponse(r, "poubelle> ", 0, func(line string) error {
+				if strings.Contains(strings.TrimSuffix(line, "\n"), "\n") {
+					t.Errorf("%s %q: passed %q as one line", name, in, line)
+				}
+				got.WriteString(line)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != want {
+				t.Errorf("%s %q: got %q, want %q", name, in, got.String(), want)
+			}
+		}
+	}
+}
+
+func TestScanResponseLimit(t *testing.T) {
+	in := "0123\n456789poubelle> "
+	each := func(string) error { return nil }
+
+	if err := scanResponse(bufio.NewReader(strings.NewReader(in)), "poubelle> ", int64(len(in)), each); err != nil {
+		t.Errorf("response at the limit failed: %v", err)
+	}
+
+	var tooLarge *ResponseTooLargeError
+	for _, r := range []*bufio.Reader{
+		bufio.NewReader(strings.NewReader(in)),
+		bufio.NewReader(iotest.OneByteReader(strings.NewReader(in + strings.Repeat("x", 100)))),
+	} {
+		if err := scanResponse(r, "poubelle> ", int64(len(in)-1), each); !errors.As(err, &tooLarge) {
+			t.Errorf("error = %v, want *ResponseTooLargeError", err)
+		}
+	}
+}
+
+func TestScanResponseDrainsAfterError(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("a\nb\nc\npoubelle> next"))
+	failure := errors.New("write failed")
+	calls := 0
+	err := scanResponse(r, "poubelle> ", 0, func(string) error {
+		calls++
+		return failure
+	})
+	if err != failure || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the first error after 1", err, calls)
+	}
+	if rest, _ := r.ReadString(0); rest != "next" {
+		t.Errorf("left %q unread, want %q", rest, "next")
+	}
+}
+
+func benchmarkRead(b *testing.B, read func(*bufio.Reader, string, int64) (string, error), rows int) {
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		sb.WriteString(`{"id": Int(1), "name": Text("Alice")}` + "\n")
+	}
+	sb.WriteString("poubelle> ")
+	response := sb.String()
+
+	r := strings.NewReader(response)
+	reader := bufio.NewReader(r)
+	b.SetBytes(int64(len(response)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset(response)
+		reader.Reset(r)
+		if _, err := read(reader, "poubelle> ", 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadUntilPromptSmall(b *testing.B) { benchmarkRead(b, readUntilPrompt, 1) }
+func BenchmarkReadUntilPromptLarge(b *testing.B) { benchmarkRead(b, readUntilPrompt, 1000) }
+func BenchmarkReadUntilPromptByByteSmall(b *testing.B) {
+	benchmarkRead(b, readUntilPromptByByte, 1)
+}
+func BenchmarkReadUntilPromptByByteLarge(b *testing.B) {
+	benchmarkRead(b, readUntilPromptByByte, 1000)
+}
+
+func TestWaitForAnyPrompt(t *testing.T) {
+	tests := []struct {
+		in, want, rest string
+	}{
+		{"Username: ", "Username: ", ""},
+		{"Welcome\nUsername: Password: ", "Username: ", "Password: "},
+		{"Challenge: abc\n", "Challenge: ", "abc\n"},
+		{"Connected to Poubelle DB\npoubelle> ", "Connected to Poubelle DB", "\npoubelle> "},
+	}
+	prompts := []string{"Username: ", "Password: ", "Challenge: ", "Connected to Poubelle DB"}
+
+	for _, tt := range tests {
+		for name, r := range map[string]*bufio.Reader{
+			"buffered": bufio.NewReader(strings.NewReader(tt.in)),
+			"one byte": bufio.NewReader(iotest.OneByteReader(strings.NewReader(tt.in))),
+			"small":    bufio.NewReaderSize(iotest.HalfReader(strings.NewReader(tt.in)), 16),
+		} {
+			got, err := waitForAnyPrompt(r, prompts...)
+			if err != nil {
+				t.Fatalf("%s %q: %v", name, tt.in, err)
+			}
+			rest, _ := r.ReadString(0)
+			if got != tt.want || rest != tt.rest {
+				t.Errorf("%s %q: got %q then %q, want %q then %q", name, tt.in, got, rest, tt.want, tt.rest)
+			}
+		}
+	}
+
+	if _, err := waitForAnyPrompt(bufio.NewReader(strings.NewReader("no prompt")), prompts...); err == nil {
+		t.Error("expected an error when the prompt never arrives")
+	}
+}
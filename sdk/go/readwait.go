@@ -0,0 +1,31 @@
+package poubelle
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// waitReader adds the time each Read of the connection spends blocked to
+// blocked. The client's buffered reader only calls Read once it has
+// handed out everything buffered, so with a slow server, or a slow
+// consumer that lets TCP push back on the server, this is the time spent
+// waiting for the network rather than parsing.
+type waitReader struct {
+	r       io.Reader
+	blocked *atomic.Int64
+}
+
+func (w *waitReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.r.Read(p)
+	w.blocked.Add(int64(time.Since(start)))
+	return n, err
+}
+
+// ReadWaitTime returns the total time the client and its WithContext
+// copies have spent blocked reading from the server since the client was
+// created, across reconnects. It is measured on the system clock.
+func (c *Client) ReadWaitTime() time.Duration {
+	return time.Duration(c.readWait.Load())
+}
@@ -0,0 +1,63 @@
+package poubelle
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadWaitTimeSlowStream(t *testing.T) {
+	const chunks, pause = 10, 20 * time.Millisecond
+	row := `{"id": Int(1), "name": Text("Alice")}` + "\n"
+
+	s := &mockServer{handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+		if !defaultHandshake(conn, reader) {
+			return false
+		}
+		fmt.Fprint(conn, "poubelle> ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		for i := 0; i < chunks; i++ {
+			time.Sleep(pause)
+			fmt.Fprint(conn, strings.Repeat(row, 500))
+		}
+		fmt.Fprint(conn, "poubelle> ")
+		reader.ReadString('\n')
+		return false
+	}}
+	s.start(t)
+
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	before := client.ReadWaitTime()
+	start := time.Now()
+	rows, err := client.Execute("SELECT * FROM users")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != chunks*500 {
+		t.Fatalf("got %d rows, want %d", len(rows), chunks*500)
+	}
+
+	blocked := client.ReadWaitTime() - before
+	if blocked < chunks*pause*8/10 || blocked > elapsed {
+		t.Errorf("read wait = %v over %v, want at least %v", blocked, elapsed, chunks*pause*8/10)
+	}
+	// Time not spent blocked is spent reading and parsing; busy-waiting on
+	// the slow stream would make it dominate.
+	if busy := elapsed - blocked; busy > elapsed/2 {
+		t.Errorf("busy for %v of %v, want the client to block while the server is slow", busy, elapsed)
+	}
+}
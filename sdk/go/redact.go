@@ -0,0 +1,42 @@
+package poubelle
+
+import "strings"
+
+// redactedDSN stands in for a connection string that could not be parsed,
+// since there is no telling which part of it is the password.
+const redactedDSN = "poubelle://<redacted>"
+
+// RedactDSN returns connectionString with its password replaced by ****,
+// safe to log. A connection string NewClient would reject is replaced as a
+// whole by a placeholder.
+func RedactDSN(connectionString string) string {
+	cfg, err := parseConnectionString(connectionString)
+	if err != nil {
+		return redactedDSN
+	}
+	return formatDSN(cfg.tls, cfg.username, cfg.hosts)
+}
+
+// String returns the client's connection string with the password
+// replaced by ****.
+func (c *Client) String() string {
+	return formatDSN(c.useTLS, c.username, c.hosts)
+}
+
+func formatDSN(useTLS bool, username string, hosts []hostPort) string {
+	var b strings.Builder
+	b.WriteString("poubelle")
+	if useTLS {
+		b.WriteByte('s')
+	}
+	b.WriteString("://")
+	b.WriteString(username)
+	b.WriteString(":****@")
+	for i, h := range hosts {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Retryer decides whether a failed operation is tried again. Retry is
+// called with the error and the number of attempts made so far, starting at
+// 1, and returns how long to wait before the next attempt and whether to
+// make it.
+type Retryer interface {
+	Retry(err error, attempt int) (wait time.Duration, retry bool)
+}
+
+// ExponentialBackoff is a Retryer that waits Initial before the first
+// retry and doubles the wait each time, up to Max, giving up after
+// MaxAttempts attempts in total. Errors from a cancelled or expired context
+// and errors reported by the server are never retried.
+type ExponentialBackoff struct {
+	Initial     time.Duration
+	Max         time.Duration
+	MaxAttempts int
+}
+
+// DefaultRetryPolicy retries up to four times, waiting 100ms, 200ms, 400ms
+// and 800ms.
+var DefaultRetryPolicy = ExponentialBackoff{
+	Initial:     100 * time.Millisecond,
+	Max:         5 * time.Second,
+	MaxAttempts: 5,
+}
+
+func (b ExponentialBackoff) Retry(err error, attempt int) (time.Duration, bool) {
+	if attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrClientClosed) {
+		return 0, false
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return 0, false
+	}
+
+	wait := b.Initial
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if b.Max > 0 && wait >= b.Max {
+			return b.Max, true
+		}
+	}
+	return wait, true
+}
+
+// WithRetryPolicy makes Connect consult r when connecting fails, and try
+// again for as long as r allows. Rejected credentials and an unsupported
+// server version are not retried, since they would fail again. See
+// WithRetryReads to apply r to queries.
+func WithRetryPolicy(r Retryer) Option {
+	return func(c *Client) {
+		c.retry = r
+	}
+}
+
+// WithRetryReads applies the retry policy to statements ClassifyStatement
+// reports as reads, which are safe to run again. Before each retry the
+// client reconnects if the failure dropped the connection.
+func WithRetryReads(enabled bool) Option {
+	return func(c *Client) {
+		c.retryReads = enabled
+	}
+}
+
+// WithBackoffJitter randomizes every wait between retries, from the retry
+// policy or RetryOpts.Backoff, to within fraction of its length below it:
+// a wait d becomes a random duration between d*(1-fraction) and d. This
+// keeps clients that failed together from retrying in lockstep. The default
+// is 1, full jitter; 0 uses waits exactly.
+func WithBackoffJitter(fraction float64) Option {
+	return func(c *Client) {
+		c.backoffJitter = min(max(fraction, 0), 1)
+	}
+}
+
+// jitter applies the client's backoff jitter to d.
+func (c *Client) jitter(d time.Duration) time.Duration {
+	if d <= 0 || c.backoffJitter == 0 {
+		return d
+	}
+	return d - time.Duration(rand.Float64()*c.backoffJitter*float64(d))
+}
+
+// connectWithRetry connects, consulting the retry policy after each
+// failure. It is called with c.mu held.
+func (c *Client) connectWithRetry(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := c.connect(ctx)
+		if err == nil || c.retry == nil || errors.Is(err, ErrAuthenticationFailed) || errors.Is(err, ErrUnsupportedServerVersion) {
+			return err
+		}
+		wait, ok := c.retry.Retry(err, attempt)
+		if !ok {
+			return err
+		}
+		wait = c.jitter(wait)
+		c.logf("poubelle: connect attempt %d failed, retrying in %v: %v", attempt, wait, err)
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// queryWithRetry runs a read, consulting the retry policy after each
+// failure, with c.mu held.
+func (c *Client) queryWithRetry(ctx context.Context, sql string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := c.query(ctx, sql)
+		if err == nil {
+			return result, nil
+		}
+		wait, ok := c.retry.Retry(err, attempt)
+		if !ok || c.isClosed() {
+			return result, err
+		}
+		if err := c.sleep(ctx, c.jitter(wait)); err != nil {
+			return "", err
+		}
+		if c.conn == nil {
+			if cerr := c.connect(ctx); cerr != nil {
+				return "", cerr
+			}
+		}
+	}
+}
+
+func (c *Client) retriesQuery(sql string) bool {
+	return c.retry != nil && c.retryReads && ClassifyStatement(sql) == Read
+}
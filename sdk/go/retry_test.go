@@ -0,0 +1,180 @@
+package poubelle
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// retryTwice retries exactly twice, without waiting, and records what it
+// was asked.
+type retryTwice struct {
+	calls []int
+}
+
+func (r *retryTwice) Retry(err error, attempt int) (time.Duration, bool) {
+	r.calls = append(r.calls, attempt)
+	return 0, attempt <= 2
+}
+
+// droppingServer hangs up on its first drops connections before the
+// handshake.
+func droppingServer(t *testing.T, drops int32) (*mockServer, *atomic.Int32) {
+	var attempts atomic.Int32
+	s := &mockServer{
+		handle: func(query string) string { return "ok\n" },
+		handshake: func(conn net.Conn, reader *bufio.Reader) bool {
+			if attempts.Add(1) <= drops {
+				return false
+			}
+			return defaultHandshake(conn, reader)
+		},
+	}
+	s.start(t)
+	return s, &attempts
+}
+
+func TestConnectRetryPolicy(t *testing.T) {
+	s, attempts := droppingServer(t, 2)
+	policy := &retryTwice{}
+	client, err := NewClient(s.dsn(), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("Connect() = %v, want success on the third attempt", err)
+	}
+	defer client.Close()
+
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("server saw %d connections, want 3", n)
+	}
+	if len(policy.calls) != 2 {
+		t.Errorf("policy consulted %v, want twice", policy.calls)
+	}
+
+	s, attempts = droppingServer(t, 3)
+	policy = &retryTwice{}
+	client, err = NewClient(s.dsn(), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err == nil {
+		t.Fatal("Connect() succeeded, want the policy to give up")
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("server saw %d connections, want 3", n)
+	}
+	if len(policy.calls) != 3 {
+		t.Errorf("policy consulted %v, want 3 times", policy.calls)
+	}
+}
+
+func TestConnectRetryAuthFailure(t *testing.T) {
+	s := newMockServer(t, func(string) string { return "" })
+	policy := &retryTwice{}
+	client, err := NewClient(strings.Replace(s.dsn(), "admin:admin", "admin:wrong", 1), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("Connect() = %v, want ErrAuthenticationFailed", err)
+	}
+	if len(policy.calls) != 0 {
+		t.Errorf("policy consulted %v for bad credentials", policy.calls)
+	}
+}
+
+func TestRetryReads(t *testing.T) {
+	var queries atomic.Int32
+	s := newMockServer(t, func(query string) string {
+		if queries.Add(1) <= 2 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return "{\"id\": Int(1)}\n"
+	})
+
+	policy := &retryTwice{}
+	client, err := NewClient(s.dsn(), WithRetryPolicy(policy), WithRetryReads(true), WithReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	rows, err := client.Execute("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Execute() = %v, want success after two retries", err)
+	}
+	if len(rows) != 1 || len(policy.calls) != 2 {
+		t.Errorf("rows = %v, policy calls = %v", rows, policy.calls)
+	}
+
+	policy.calls = nil
+	queries.Store(0)
+	if _, err := client.Query("INSERT INTO t (id) VALUES (1)"); err == nil {
+		t.Error("write succeeded after timing out")
+	}
+	if len(policy.calls) != 0 {
+		t.Errorf("policy consulted %v for a write", policy.calls)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: 10 * time.Millisecond, Max: 30 * time.Millisecond, MaxAttempts: 4}
+
+	var waits []time.Duration
+	for attempt := 1; ; attempt++ {
+		wait, ok := b.Retry(ErrConnectionClosed, attempt)
+		if !ok {
+			break
+		}
+		waits = append(waits, wait)
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	if len(waits) != len(want) {
+		t.Fatalf("waits = %v, want %v", waits, want)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("waits = %v, want %v", waits, want)
+		}
+	}
+
+	if _, ok := b.Retry(&ServerError{Message: "syntax error"}, 1); ok {
+		t.Error("server error was retried")
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	const d = 100 * time.Millisecond
+	for _, fraction := range []float64{1, 0.25, 0} {
+		c := newClient(connConfig{}, []Option{WithBackoffJitter(fraction)})
+		lo := time.Duration(float64(d) * (1 - fraction))
+
+		seen := map[time.Duration]bool{}
+		for range 1000 {
+			wait := c.jitter(d)
+			if wait < lo || wait > d {
+				t.Fatalf("jitter %v: wait %v outside [%v, %v]", fraction, wait, lo, d)
+			}
+			seen[wait] = true
+		}
+		if fraction == 0 && len(seen) != 1 {
+			t.Errorf("no jitter produced %d distinct waits", len(seen))
+		}
+		if fraction > 0 && len(seen) < 100 {
+			t.Errorf("jitter %v produced only %d distinct waits", fraction, len(seen))
+		}
+	}
+
+	if c := newClient(connConfig{}, nil); c.backoffJitter != 1 {
+		t.Errorf("default jitter = %v, want full jitter", c.backoffJitter)
+	}
+}
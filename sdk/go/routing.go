@@ -0,0 +1,209 @@
+package poubelle
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// RoutingPool sends reads to read replicas and everything else to a
+// primary. Statements are routed with ClassifyStatement; reads are spread
+// round-robin over the replicas, or go to the primary when there are none.
+// Transactions always run on the primary.
+type RoutingPool struct {
+	primary  *routeTarget
+	replicas []*routeTarget
+	next     atomic.Uint64
+}
+
+type routeTarget struct {
+	addr         string
+	pool         *Pool
+	queries      atomic.Int64
+	transactions atomic.Int64
+}
+
+// TargetStats describes the use of one RoutingPool target.
+type TargetStats struct {
+	// Addr is the target's connection string with the password redacted,
+	// listing every host of a multi-host DSN.
+	Addr    string
+	Primary bool
+	// Queries counts the statements routed to the target by Query and
+	// Execute, and Transactions the transactions started on it.
+	Queries      int64
+	Transactions int64
+	Pool         PoolStats
+}
+
+// NewRoutingPool creates a pool of connections to primary and to each of
+// replicas, all configured with opts.
+func NewRoutingPool(primary string, replicas []string, opts ...PoolOption) (*RoutingPool, error) {
+	p := &RoutingPool{}
+
+	target, err := newRouteTarget(primary, opts)
+	if err != nil {
+		return nil, fmt.Errorf("primary: %w", err)
+	}
+	p.primary = target
+
+	for i, dsn := range replicas {
+		target, err := newRouteTarget(dsn, opts)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		p.replicas = append(p.replicas, target)
+	}
+
+	return p, nil
+}
+
+func newRouteTarget(dsn string, opts []PoolOption) (*routeTarget, error) {
+	cfg, err := parseConnectionString(dsn)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := NewPool(dsn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &routeTarget{addr: formatDSN(cfg.tls, cfg.username, cfg.hosts), pool: pool}, nil
+}
+
+// Target selects where RoutingPool.QueryOn runs a statement.
+type Target int
+
+const (
+	// Routed runs the statement where Query would: on a replica when it
+	// is a read, on the primary otherwise.
+	Routed Target = iota
+	// Primary always runs the statement on the primary, for reads that
+	// must see the caller's own recent writes.
+	Primary
+)
+
+// route picks the target for sql.
+func (p *RoutingPool) route(sql string) *routeTarget {
+	if len(p.replicas) == 0 || ClassifyStatement(sql) != Read {
+		return p.primary
+	}
+	n := p.next.Add(1) - 1
+	return p.replicas[n%uint64(len(p.replicas))]
+}
+
+// pick returns target, or the target route chooses for sql if it is Routed.
+func (p *RoutingPool) pick(target Target, sql string) *routeTarget {
+	if target == Primary {
+		return p.primary
+	}
+	return p.route(sql)
+}
+
+// Query runs sql on the target chosen for it and returns the raw result.
+func (p *RoutingPool) Query(ctx context.Context, sql string) (string, error) {
+	return p.QueryOn(ctx, Routed, sql)
+}
+
+// QueryOn runs sql on target and returns the raw result. Use Primary for a
+// read that follows a write, since replicas may not have applied it yet.
+func (p *RoutingPool) QueryOn(ctx context.Context, target Target, sql string) (string, error) {
+	t := p.pick(target, sql)
+	client, release, err := t.pool.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	t.queries.Add(1)
+	return client.WithContext(ctx).Query(sql)
+}
+
+// Execute runs sql on the target chosen for it and parses the rows.
+func (p *RoutingPool) Execute(ctx context.Context, sql string) ([]Row, error) {
+	return p.ExecuteOn(ctx, Routed, sql)
+}
+
+// ExecuteOn runs sql on target and parses the rows with the pooled
+// client's parsing options. A server error is returned as a *ServerError.
+func (p *RoutingPool) ExecuteOn(ctx context.Context, target Target, sql string) ([]Row, error) {
+	t := p.pick(target, sql)
+	client, release, err := t.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	t.queries.Add(1)
+	client = client.WithContext(ctx)
+	result, err := client.queryArgs(sql, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+	return client.decodeRows(result)
+}
+
+// Begin starts a transaction on a primary connection, with opts as in
+// Client.Begin. The connection returns to the pool when the transaction is
+// committed or rolled back.
+func (p *RoutingPool) Begin(ctx context.Context, opts ...TxOption) (*Tx, error) {
+	client, release, err := p.primary.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := client.WithContext(ctx).Begin(opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	p.primary.transactions.Add(1)
+	tx.onDone = release
+	return tx, nil
+}
+
+// WithTransaction runs fn in a transaction on a primary connection, with the
+// retry behaviour of Client.WithTransaction.
+func (p *RoutingPool) WithTransaction(ctx context.Context, fn func(*Tx) error, opts RetryOpts) error {
+	client, release, err := p.primary.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	p.primary.transactions.Add(1)
+	return client.WithContext(ctx).WithTransaction(fn, opts)
+}
+
+// Stats returns the usage of the primary followed by each replica.
+func (p *RoutingPool) Stats() []TargetStats {
+	stats := []TargetStats{p.primary.stats(true)}
+	for _, r := range p.replicas {
+		stats = append(stats, r.stats(false))
+	}
+	return stats
+}
+
+func (t *routeTarget) stats(primary bool) TargetStats {
+	return TargetStats{
+		Addr:         t.addr,
+		Primary:      primary,
+		Queries:      t.queries.Load(),
+		Transactions: t.transactions.Load(),
+		Pool:         t.pool.Stats(),
+	}
+}
+
+// Close closes every target's pool.
+func (p *RoutingPool) Close() error {
+	var firstErr error
+	for _, t := range append([]*routeTarget{p.primary}, p.replicas...) {
+		if err := t.pool.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
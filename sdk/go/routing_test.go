@@ -0,0 +1,177 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newRoutingMock(t *testing.T, replicas int) *RoutingPool {
+	t.Helper()
+
+	named := func(name string) string {
+		return newMockServer(t, func(query string) string { return name + "\n" }).dsn()
+	}
+
+	var replicaDSNs []string
+	for i := 0; i < replicas; i++ {
+		replicaDSNs = append(replicaDSNs, named(fmt.Sprintf("replica%d", i+1)))
+	}
+	pool, err := NewRoutingPool(named("primary"), replicaDSNs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestRoutingPoolRoutes(t *testing.T) {
+	pool := newRoutingMock(t, 2)
+	ctx := context.Background()
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM users", "replica1"},
+		{"SELECT * FROM users", "replica2"},
+		{"INSERT INTO users (id) VALUES (1)", "primary"},
+		{"WITH u AS (SELECT 1) SELECT * FROM u", "replica1"},
+		{"CREATE TABLE t (id INT)", "primary"},
+		{"/* trace */ SELECT 1", "replica2"},
+	}
+	for _, tt := range tests {
+		got, err := pool.Query(ctx, tt.sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Query(%q) ran on %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+
+	stats := pool.Stats()
+	if len(stats) != 3 || !stats[0].Primary || stats[1].Primary {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	for i, want := range []int64{2, 2, 2} {
+		if stats[i].Queries != want {
+			t.Errorf("target %d Queries = %d, want %d", i, stats[i].Queries, want)
+		}
+	}
+}
+
+func TestRoutingPoolTransactionOnPrimary(t *testing.T) {
+	pool := newRoutingMock(t, 1)
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tx.Query("SELECT * FROM users"); err != nil || got != "primary" {
+		t.Errorf("tx.Query() = %q, %v, want primary", got, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := pool.Stats()[0]; stats.Pool.InUse != 0 || stats.Transactions != 1 {
+		t.Errorf("primary stats after commit = %+v", stats)
+	}
+
+	err = pool.WithTransaction(ctx, func(tx *Tx) error {
+		got, err := tx.Query("SELECT * FROM users")
+		if err == nil && got != "primary" {
+			t.Errorf("WithTransaction read ran on %s, want primary", got)
+		}
+		return err
+	}, RetryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := pool.Stats()[1]; stats.Queries != 0 {
+		t.Errorf("replica received %d statements during transactions", stats.Queries)
+	}
+}
+
+func TestRoutingPoolWithoutReplicas(t *testing.T) {
+	pool := newRoutingMock(t, 0)
+
+	if got, err := pool.Query(context.Background(), "SELECT 1"); err != nil || got != "primary" {
+		t.Errorf("Query() = %q, %v, want primary", got, err)
+	}
+}
+
+func TestRoutingPoolQueryOnPrimary(t *testing.T) {
+	pool := newRoutingMock(t, 2)
+	ctx := context.Background()
+
+	if _, err := pool.Query(ctx, "INSERT INTO users (id) VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := pool.QueryOn(ctx, Primary, "SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "primary" {
+		t.Errorf("pinned read ran on %s, want primary", got)
+	}
+	if got, err := pool.QueryOn(ctx, Routed, "SELECT * FROM users"); err != nil || got != "replica1" {
+		t.Errorf("routed read = %q, %v, want replica1", got, err)
+	}
+
+	stats := pool.Stats()
+	for i, want := range []int64{2, 1, 0} {
+		if stats[i].Queries != want {
+			t.Errorf("target %d Queries = %d, want %d", i, stats[i].Queries, want)
+		}
+	}
+}
+
+func TestRoutingPoolExecuteErrors(t *testing.T) {
+	handle := func(query string) string {
+		if strings.Contains(query, "missing") {
+			return "Error: Table 'missing' not found\n"
+		}
+		return "{\"id\": Int(1), \"id\": Int(2)}\n"
+	}
+	dsn := newMockServer(t, handle).dsn()
+	pool, err := NewRoutingPool(dsn, []string{dsn}, WithClientOptions(WithStrictParsing(true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	var serverErr *ServerError
+	if _, err := pool.Execute(ctx, "SELECT * FROM missing"); !errors.As(err, &serverErr) {
+		t.Errorf("Execute() error = %v, want *ServerError", err)
+	}
+	if _, err := pool.ExecuteOn(ctx, Primary, "SELECT * FROM missing"); !errors.As(err, &serverErr) {
+		t.Errorf("ExecuteOn() error = %v, want *ServerError", err)
+	}
+	var parseErr *ParseError
+	if _, err := pool.Execute(ctx, "SELECT a.id, b.id FROM a, b"); !errors.As(err, &parseErr) {
+		t.Errorf("Execute() error = %v, want *ParseError in strict mode", err)
+	}
+}
+
+func TestRoutingPoolStatsLabelsAllHosts(t *testing.T) {
+	dsn := newMockServer(t, func(query string) string { return "ok\n" }).dsn() + ",127.0.0.1:1"
+	pool, err := NewRoutingPool(dsn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	addr := pool.Stats()[0].Addr
+	if want := RedactDSN(dsn); addr != want {
+		t.Errorf("Addr = %q, want %q", addr, want)
+	}
+	if !strings.Contains(addr, ",127.0.0.1:1") || strings.Contains(addr, ":admin@") {
+		t.Errorf("Addr = %q, want every host and no password", addr)
+	}
+}
@@ -0,0 +1,224 @@
+package poubelle
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Get returns the value of the column and whether the row contains it. The
+// name is matched exactly first and then ignoring case, since the server may
+// return identifiers in a different case than the query used. If several
+// columns differ only in case and none matches exactly, the one that sorts
+// first is used. The getters below look columns up the same way.
+func (r Row) Get(key string) (interface{}, bool) {
+	if v, ok := r[key]; ok {
+		return v, true
+	}
+	match, found := "", false
+	for k := range r {
+		if strings.EqualFold(k, key) && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return r[match], true
+}
+
+// Has reports whether the row contains the column, even if its value is NULL.
+// The pointer getters return nil for both, so use Has, or Get, to tell a
+// NULL column from a missing one.
+func (r Row) Has(key string) bool {
+	_, ok := r.Get(key)
+	return ok
+}
+
+// get is Get without the presence result.
+func (r Row) get(key string) interface{} {
+	v, _ := r.Get(key)
+	return v
+}
+
+// IntPtr returns the column as an int64, or nil if the column is missing,
+// NULL or not an integer. Integral float64 values, as decoded by
+// ExecuteJSON, are accepted.
+func (r Row) IntPtr(key string) *int64 {
+	switch v := r.get(key).(type) {
+	case int64:
+		return &v
+	case int:
+		n := int64(v)
+		return &n
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			n := int64(v)
+			return &n
+		}
+	}
+	return nil
+}
+
+// BigInt returns the column as a *big.Int, and false if the column is
+// missing, NULL or not an integer. Integers of any size are accepted, as
+// are integral float64 values.
+func (r Row) BigInt(key string) (*big.Int, bool) {
+	return toBigInt(r.get(key))
+}
+
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, false
+		}
+		return new(big.Int).Set(v), true
+	case int64:
+		return big.NewInt(v), true
+	case int:
+		return big.NewInt(int64(v)), true
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			n, _ := big.NewFloat(v).Int(nil)
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// StringPtr returns the column as a string, or nil if the column is missing,
+// NULL or not text.
+func (r Row) StringPtr(key string) *string {
+	if v, ok := r.get(key).(string); ok {
+		return &v
+	}
+	return nil
+}
+
+// BigIntPtr is BigInt returning nil instead of false.
+func (r Row) BigIntPtr(key string) *big.Int {
+	n, _ := r.BigInt(key)
+	return n
+}
+
+// FloatPtr returns the column as a float64, or nil if the column is
+// missing, NULL or not a number. Integers are converted, possibly losing
+// precision beyond 2^53.
+func (r Row) FloatPtr(key string) *float64 {
+	var f float64
+	switch v := r.get(key).(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	case int:
+		f = float64(v)
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		f, _ = new(big.Float).SetInt(v).Float64()
+	default:
+		return nil
+	}
+	return &f
+}
+
+// BoolPtr returns the column as a bool, or nil if the column is missing,
+// NULL or not a boolean. The debug format has no boolean type, so only
+// rows decoded from JSON, as by ExecuteJSON, hold booleans.
+func (r Row) BoolPtr(key string) *bool {
+	if v, ok := r.get(key).(bool); ok {
+		return &v
+	}
+	return nil
+}
+
+// RowsEqual reports whether a and b hold the same rows in the same order.
+// Numbers are compared by value, so int, int64 and integral float64 values
+// that are numerically equal match.
+func RowsEqual(a, b []Row) bool {
+	return DiffRows(a, b) == ""
+}
+
+// DiffRows describes how b differs from a, one difference per line, or
+// returns "" when they are equal under the rules of RowsEqual.
+func DiffRows(a, b []Row) string {
+	var diff strings.Builder
+	if len(a) != len(b) {
+		fmt.Fprintf(&diff, "row count: %d != %d\n", len(a), len(b))
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		keys := make(map[string]bool)
+		for k := range a[i] {
+			keys[k] = true
+		}
+		for k := range b[i] {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			av, aok := a[i][k]
+			bv, bok := b[i][k]
+			switch {
+			case !bok:
+				fmt.Fprintf(&diff, "row %d: %q missing from b (a has %#v)\n", i, k, av)
+			case !aok:
+				fmt.Fprintf(&diff, "row %d: %q missing from a (b has %#v)\n", i, k, bv)
+			case !valuesEqual(av, bv):
+				fmt.Fprintf(&diff, "row %d: %q: %#v != %#v\n", i, k, av, bv)
+			}
+		}
+	}
+
+	return diff.String()
+}
+
+func valuesEqual(a, b interface{}) bool {
+	_, abig := a.(*big.Int)
+	_, bbig := b.(*big.Int)
+	if abig || bbig {
+		an, aok := toBigInt(a)
+		bn, bok := toBigInt(b)
+		return aok && bok && an.Cmp(bn) == 0
+	}
+
+	an, aok := normalizeNumber(a)
+	bn, bok := normalizeNumber(b)
+	if aok && bok {
+		return an == bn
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// normalizeNumber converts any Go numeric value to int64 when it is integral
+// and in range, or to float64 otherwise.
+func normalizeNumber(v interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), true
+		}
+		return f, true
+	}
+	return nil, false
+}
@@ -0,0 +1,197 @@
+package poubelle
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRowPointerGetters(t *testing.T) {
+	row := parseRow(`{"id": Int(7), "name": Text("Alice"), "email": Null}`)
+
+	if p := row.IntPtr("id"); p == nil || *p != 7 {
+		t.Errorf("IntPtr(id) = %v, want 7", p)
+	}
+	if p := row.StringPtr("name"); p == nil || *p != "Alice" {
+		t.Errorf("StringPtr(name) = %v, want Alice", p)
+	}
+
+	if p := row.StringPtr("email"); p != nil {
+		t.Errorf("StringPtr(email) = %q, want nil for NULL", *p)
+	}
+	if !row.Has("email") {
+		t.Error("Has(email) = false for a NULL column")
+	}
+
+	if p := row.IntPtr("missing"); p != nil {
+		t.Errorf("IntPtr(missing) = %d, want nil", *p)
+	}
+	if row.Has("missing") {
+		t.Error("Has(missing) = true for an absent column")
+	}
+
+	if p := row.IntPtr("name"); p != nil {
+		t.Errorf("IntPtr(name) = %d, want nil for a text column", *p)
+	}
+}
+
+func TestRowNumericAndBoolPointerGetters(t *testing.T) {
+	row := parseRow(`{"big": Int(99999999999999999999), "id": Int(7), "ratio": Float(0.5), "name": Text("x"), "gone": Null}`)
+
+	if p := row.BigIntPtr("big"); p == nil || p.String() != "99999999999999999999" {
+		t.Errorf("BigIntPtr(big) = %v", p)
+	}
+	if p := row.BigIntPtr("id"); p == nil || p.Int64() != 7 {
+		t.Errorf("BigIntPtr(id) = %v, want 7", p)
+	}
+	if p := row.FloatPtr("ratio"); p == nil || *p != 0.5 {
+		t.Errorf("FloatPtr(ratio) = %v, want 0.5", p)
+	}
+	if p := row.FloatPtr("id"); p == nil || *p != 7 {
+		t.Errorf("FloatPtr(id) = %v, want 7", p)
+	}
+	if p := row.FloatPtr("big"); p == nil || *p != 1e20 {
+		t.Errorf("FloatPtr(big) = %v, want 1e20", p)
+	}
+	for _, key := range []string{"name", "gone", "missing"} {
+		if row.BigIntPtr(key) != nil || row.FloatPtr(key) != nil || row.BoolPtr(key) != nil {
+			t.Errorf("getters of %s should all be nil", key)
+		}
+	}
+
+	json := Row{"active": true, "id": float64(3)}
+	if p := json.BoolPtr("active"); p == nil || !*p {
+		t.Errorf("BoolPtr(active) = %v, want true", p)
+	}
+	if p := json.BoolPtr("id"); p != nil {
+		t.Errorf("BoolPtr(id) = %v, want nil for a number", *p)
+	}
+}
+
+func TestRowIntPtrFromJSON(t *testing.T) {
+	row := Row{"id": float64(3), "ratio": 0.5}
+
+	if p := row.IntPtr("id"); p == nil || *p != 3 {
+		t.Errorf("IntPtr(id) = %v, want 3", p)
+	}
+	if p := row.IntPtr("ratio"); p != nil {
+		t.Errorf("IntPtr(ratio) = %d, want nil for a fractional number", *p)
+	}
+}
+
+func TestRowsEqual(t *testing.T) {
+	debug := []Row{{"id": int64(1), "name": "Alice", "email": nil}}
+	json := []Row{{"id": float64(1), "name": "Alice", "email": nil}}
+	literal := []Row{{"id": 1, "name": "Alice", "email": nil}}
+
+	if !RowsEqual(debug, json) || !RowsEqual(debug, literal) {
+		t.Errorf("numerically equal rows compared unequal:\n%s", DiffRows(debug, json))
+	}
+	if RowsEqual(debug, []Row{{"id": 1.5, "name": "Alice", "email": nil}}) {
+		t.Error("1 and 1.5 compared equal")
+	}
+	if RowsEqual(debug, append(debug, Row{})) {
+		t.Error("rows of different length compared equal")
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	a := []Row{{"id": int64(1), "name": "Alice"}, {"id": int64(2)}}
+	b := []Row{{"id": 1, "name": "Alicia"}, {"id": int64(2), "age": int64(30)}}
+
+	diff := DiffRows(a, b)
+	for _, want := range []string{
+		`row 0: "name": "Alice" != "Alicia"`,
+		`row 1: "age" missing from a`,
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, `"id"`) {
+		t.Errorf("diff reports equal ids:\n%s", diff)
+	}
+}
+
+func TestRowBigInt(t *testing.T) {
+	rows := parseRows(`{"max": BigInt(170141183460469231731687303715884105727), "min": BigInt(-170141183460469231731687303715884105728), "over": Int(9223372036854775808), "small": Int(42), "name": Text("x"), "none": Null}`)
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	row := rows[0]
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"max", "170141183460469231731687303715884105727"},
+		{"min", "-170141183460469231731687303715884105728"},
+		{"over", "9223372036854775808"},
+		{"small", "42"},
+	}
+	for _, tt := range tests {
+		n, ok := row.BigInt(tt.key)
+		if !ok || n.String() != tt.want {
+			t.Errorf("BigInt(%q) = %v, %v, want %s", tt.key, n, ok, tt.want)
+		}
+	}
+
+	if _, ok := row["over"].(*big.Int); !ok {
+		t.Errorf("overflowing Int decoded as %T, want *big.Int", row["over"])
+	}
+	for _, key := range []string{"name", "none", "missing"} {
+		if n, ok := row.BigInt(key); ok {
+			t.Errorf("BigInt(%q) = %v, want false", key, n)
+		}
+	}
+
+	// The getter returns a copy.
+	n, _ := row.BigInt("max")
+	n.SetInt64(0)
+	if again, _ := row.BigInt("max"); again.Sign() == 0 {
+		t.Error("BigInt returned the row's own value")
+	}
+
+	if !RowsEqual([]Row{{"n": big.NewInt(7)}}, []Row{{"n": int64(7)}}) {
+		t.Error("RowsEqual does not compare *big.Int by value")
+	}
+}
+
+func TestRowCaseInsensitiveGet(t *testing.T) {
+	row := Row{"ID": int64(7), "Name": "Alice", "name": "alice", "EMAIL": nil}
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"ID", int64(7)},
+		{"id", int64(7)},
+		{"name", "alice"},
+		{"Name", "Alice"},
+		{"NAME", "Alice"},
+	}
+	for _, tt := range tests {
+		if v, ok := row.Get(tt.key); !ok || v != tt.want {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, v, ok, tt.want)
+		}
+	}
+	if v, ok := row.Get("email"); !ok || v != nil {
+		t.Errorf("Get(email) = %v, %v, want a NULL column", v, ok)
+	}
+	if _, ok := row.Get("missing"); ok {
+		t.Error("Get(missing) found a column")
+	}
+
+	if p := row.IntPtr("id"); p == nil || *p != 7 {
+		t.Errorf("IntPtr(id) = %v, want 7", p)
+	}
+	if n, ok := row.BigInt("Id"); !ok || n.Int64() != 7 {
+		t.Errorf("BigInt(Id) = %v, %v, want 7", n, ok)
+	}
+	if p := row.StringPtr("NAME"); p == nil || *p != "Alice" {
+		t.Errorf("StringPtr(NAME) = %v, want Alice", p)
+	}
+	if !row.Has("Email") || row.Has("missing") {
+		t.Error("Has does not match columns ignoring case")
+	}
+}
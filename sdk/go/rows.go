@@ -0,0 +1,198 @@
+package poubelle
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// Rows iterates over a result in the style of database/sql:
+//
+//	rows, err := client.ExecuteRows("SELECT id, name FROM users")
+//	...
+//	for rows.Next() {
+//		var id int64
+//		var name *string
+//		// Columns are sorted by name: id, then name.
+//		if err := rows.Scan(&id, &name); err != nil {
+//			...
+//		}
+//	}
+//
+// The whole result is read before ExecuteRows returns.
+type Rows struct {
+	batch *ColumnBatch
+	pos   int
+	err   error
+}
+
+// ExecuteRows runs sql and returns its rows for scanning with Next and Scan.
+func (c *Client) ExecuteRows(sql string, args ...interface{}) (*Rows, error) {
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+
+	batch, err := c.decodeColumnar(result)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{batch: batch, pos: -1}, nil
+}
+
+// Columns returns the column names, sorted. The server prints each row's
+// columns in no fixed order, so Scan binds destinations in this order, not
+// the order of the SELECT list.
+func (r *Rows) Columns() []string {
+	return r.batch.Columns
+}
+
+// Next advances to the next row, reporting whether there is one.
+func (r *Rows) Next() bool {
+	if r.err != nil || r.pos >= r.batch.Len {
+		return false
+	}
+	r.pos++
+	return r.pos < r.batch.Len
+}
+
+// Scan copies the columns of the current row, in Columns order, into dest.
+// Numbers convert to any numeric type that holds them, and to strings.
+// Scanning NULL requires a pointer destination such as **string, which is
+// set to nil, or *interface{}.
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.pos < 0 || r.pos >= r.batch.Len {
+		return fmt.Errorf("Scan called without a successful Next")
+	}
+	if len(dest) != len(r.batch.Columns) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(r.batch.Columns), len(dest))
+	}
+
+	for i, d := range dest {
+		if err := convertAssign(d, r.batch.Values[i][r.pos]); err != nil {
+			r.err = fmt.Errorf("column %s: %w", r.batch.Columns[i], err)
+			return r.err
+		}
+	}
+	return nil
+}
+
+// Err returns the error that stopped iteration, if any.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close ends iteration early. It exists for parity with database/sql; the
+// result already holds no connection resources.
+func (r *Rows) Close() error {
+	r.pos = r.batch.Len
+	return nil
+}
+
+// convertAssign stores src, a value decoded from a response, in the
+// variable dest points to.
+func convertAssign(dest, src interface{}) error {
+	switch d := dest.(type) {
+	case *interface{}:
+		*d = src
+		return nil
+	case *string:
+		switch s := src.(type) {
+		case string:
+			*d = s
+			return nil
+		case int64:
+			*d = strconv.FormatInt(s, 10)
+			return nil
+		case *big.Int:
+			*d = s.String()
+			return nil
+		}
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination %T is not a non-nil pointer", dest)
+	}
+	ev := dv.Elem()
+
+	if ev.Kind() == reflect.Pointer {
+		if src == nil {
+			ev.SetZero()
+			return nil
+		}
+		nv := reflect.New(ev.Type().Elem())
+		if err := convertAssign(nv.Interface(), src); err != nil {
+			return err
+		}
+		ev.Set(nv)
+		return nil
+	}
+	if src == nil {
+		return fmt.Errorf("cannot scan NULL into %T", dest)
+	}
+
+	sv := reflect.ValueOf(src)
+	switch {
+	case sv.Type().AssignableTo(ev.Type()):
+		ev.Set(sv)
+		return nil
+	case isNumericKind(sv.Kind()) && isNumericKind(ev.Kind()):
+		return convertNumber(ev, sv)
+	case sv.Kind() == reflect.String && ev.Kind() == reflect.String:
+		ev.SetString(sv.String())
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into %T", src, dest)
+}
+
+// convertNumber stores the number in sv in ev, failing rather than
+// truncating if ev cannot hold it.
+func convertNumber(ev, sv reflect.Value) error {
+	switch {
+	case ev.CanFloat():
+		if sv.CanInt() {
+			ev.SetFloat(float64(sv.Int()))
+		} else if sv.CanUint() {
+			ev.SetFloat(float64(sv.Uint()))
+		} else {
+			ev.SetFloat(sv.Float())
+		}
+		return nil
+	case sv.CanInt():
+		n := sv.Int()
+		if ev.CanInt() && !ev.OverflowInt(n) {
+			ev.SetInt(n)
+			return nil
+		}
+		if ev.CanUint() && n >= 0 && !ev.OverflowUint(uint64(n)) {
+			ev.SetUint(uint64(n))
+			return nil
+		}
+	case sv.CanUint():
+		n := sv.Uint()
+		if ev.CanUint() && !ev.OverflowUint(n) {
+			ev.SetUint(n)
+			return nil
+		}
+		if ev.CanInt() && n <= 1<<63-1 && !ev.OverflowInt(int64(n)) {
+			ev.SetInt(int64(n))
+			return nil
+		}
+	default:
+		f := sv.Float()
+		if ev.CanInt() && f == float64(int64(f)) && !ev.OverflowInt(int64(f)) {
+			ev.SetInt(int64(f))
+			return nil
+		}
+		if ev.CanUint() && f >= 0 && f == float64(uint64(f)) && !ev.OverflowUint(uint64(f)) {
+			ev.SetUint(uint64(f))
+			return nil
+		}
+	}
+	return fmt.Errorf("value %v does not fit in %s", sv.Interface(), ev.Type())
+}
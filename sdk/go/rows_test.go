@@ -0,0 +1,131 @@
+package poubelle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecuteRows(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "{\"id\": Int(1), \"name\": Text(\"Alice\"), \"nick\": Text(\"al\")}\n" +
+			"{\"id\": Int(2), \"name\": Text(\"Bob\"), \"nick\": Null}\n"
+	})
+
+	rows, err := client.ExecuteRows("SELECT id, name, nick FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows.Columns(), []string{"id", "name", "nick"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+
+	type user struct {
+		id   int64
+		name string
+		nick *string
+	}
+	var users []user
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.id, &u.name, &u.nick); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("scanned %d rows, want 2", len(users))
+	}
+	if users[0].id != 1 || users[0].name != "Alice" || users[0].nick == nil || *users[0].nick != "al" {
+		t.Errorf("first row = %+v", users[0])
+	}
+	if users[1].id != 2 || users[1].name != "Bob" || users[1].nick != nil {
+		t.Errorf("second row = %+v", users[1])
+	}
+	if rows.Next() {
+		t.Error("Next() after the last row returned true")
+	}
+}
+
+func TestExecuteRowsColumnOrder(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "{\"name\": Text(\"Alice\"), \"id\": Int(1)}\n" +
+			"{\"id\": Int(2), \"name\": Text(\"Bob\")}\n"
+	})
+
+	rows, err := client.ExecuteRows("SELECT name, id FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows.Columns(), []string{"id", "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+
+	var names []string
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[int64]string{1: "Alice", 2: "Bob"}[id]; name != want {
+			t.Errorf("row %d name = %q, want %q", id, name, want)
+		}
+		names = append(names, name)
+	}
+	if !reflect.DeepEqual(names, []string{"Alice", "Bob"}) {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestRowsScanConversions(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "{\"n\": Int(300), \"s\": Text(\"x\"), \"z\": Null}\n"
+	})
+
+	scan := func(dest ...interface{}) error {
+		rows, err := client.ExecuteRows("SELECT n, s, z FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rows.Next() {
+			t.Fatal("no row")
+		}
+		return rows.Scan(dest...)
+	}
+
+	var (
+		i int
+		f float64
+		s string
+		a interface{}
+		p *int64
+	)
+	if err := scan(&i, &s, &a); err != nil || i != 300 || s != "x" || a != nil {
+		t.Errorf("Scan() = %v: %d %q %v", err, i, s, a)
+	}
+	if err := scan(&f, &a, &p); err != nil || f != 300 || a != "x" || p != nil {
+		t.Errorf("Scan() = %v: %v %v %v", err, f, a, p)
+	}
+	if err := scan(&s, &s, &a); err != nil || s != "x" {
+		t.Errorf("Scan() = %v", err)
+	}
+
+	var small int8
+	if err := scan(&small, &s, &a); err == nil || !strings.Contains(err.Error(), "column n") {
+		t.Errorf("overflowing int8: err = %v", err)
+	}
+	if err := scan(&i, &s, &s); err == nil {
+		t.Error("scanning NULL into string succeeded")
+	}
+	if err := scan(&i, &s); err == nil {
+		t.Error("scanning with too few destinations succeeded")
+	}
+	if err := scan(&i, &i, &a); err == nil {
+		t.Error("scanning text into int succeeded")
+	}
+}
@@ -0,0 +1,102 @@
+package poubelle
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// QueryScalar runs a query that returns exactly one row with one column,
+// such as SELECT COUNT(*), and returns that value decoded as in Execute. It
+// returns ErrNoRows for an empty result, a *ServerError if the server
+// reports one, and an error for any other shape.
+func (c *Client) QueryScalar(sql string, args ...interface{}) (interface{}, error) {
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.decodeRows(result)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(rows) == 0:
+		return nil, ErrNoRows
+	case len(rows) > 1:
+		return nil, fmt.Errorf("scalar query returned %d rows, want 1", len(rows))
+	case len(rows[0]) != 1:
+		return nil, fmt.Errorf("scalar query returned %d columns, want 1", len(rows[0]))
+	}
+
+	for _, v := range rows[0] {
+		return v, nil
+	}
+	panic("unreachable")
+}
+
+// QueryRow runs a query and returns its first row, or ErrNoRows if it
+// returned none. A *ServerError is returned if the server reports one.
+func (c *Client) QueryRow(sql string, args ...interface{}) (Row, error) {
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ackError(result); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.decodeRows(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+	return rows[0], nil
+}
+
+// QueryScalarT is QueryScalar with the value converted to T. Numbers
+// convert to any numeric T that holds them exactly, as in Rows.Scan; other
+// values must already have type T, so a NULL is an error unless T is an
+// interface or pointer type.
+func QueryScalarT[T any](c *Client, sql string, args ...interface{}) (T, error) {
+	var zero T
+	v, err := c.QueryScalar(sql, args...)
+	if err != nil {
+		return zero, err
+	}
+
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	if v == nil {
+		switch reflect.TypeFor[T]().Kind() {
+		case reflect.Interface, reflect.Pointer:
+			return zero, nil
+		}
+		return zero, fmt.Errorf("scalar value is NULL, cannot convert to %T", zero)
+	}
+
+	var t T
+	sv, ev := reflect.ValueOf(v), reflect.ValueOf(&t).Elem()
+	if isNumericKind(sv.Kind()) && isNumericKind(ev.Kind()) {
+		if err := convertNumber(ev, sv); err != nil {
+			return zero, err
+		}
+		return t, nil
+	}
+	return zero, fmt.Errorf("scalar value %v has type %T, cannot convert to %T", v, v, zero)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
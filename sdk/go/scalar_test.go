@@ -0,0 +1,98 @@
+package poubelle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func scalarMock(t *testing.T) *Client {
+	return connectMock(t, func(query string) string {
+		switch {
+		case strings.Contains(query, "FROM empty"):
+			return "No rows\n"
+		case strings.Contains(query, "FROM many"):
+			return "{\"count\": Int(1)}\n{\"count\": Int(2)}\n"
+		case strings.Contains(query, "FROM wide"):
+			return "{\"a\": Int(1), \"b\": Int(2)}\n"
+		case strings.Contains(query, "FROM missing"):
+			return "Error: Table missing not found\n"
+		case strings.Contains(query, "name"):
+			return "{\"name\": Text(\"Alice\")}\n"
+		case strings.Contains(query, "FROM large"):
+			return "{\"count\": Int(300)}\n"
+		case strings.Contains(query, "FROM negative"):
+			return "{\"count\": Int(-1)}\n"
+		case strings.Contains(query, "NULL"):
+			return "{\"v\": Null}\n"
+		}
+		return "{\"count\": Int(42)}\n"
+	})
+}
+
+func TestQueryScalar(t *testing.T) {
+	client := scalarMock(t)
+
+	v, err := client.QueryScalar("SELECT COUNT(*) FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != int64(42) {
+		t.Errorf("QueryScalar() = %#v, want int64(42)", v)
+	}
+
+	if _, err := client.QueryScalar("SELECT COUNT(*) FROM empty"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("empty result: err = %v, want ErrNoRows", err)
+	}
+	if _, err := client.QueryScalar("SELECT count FROM many"); err == nil || !strings.Contains(err.Error(), "2 rows") {
+		t.Errorf("several rows: err = %v", err)
+	}
+	if _, err := client.QueryScalar("SELECT * FROM wide"); err == nil || !strings.Contains(err.Error(), "2 columns") {
+		t.Errorf("several columns: err = %v", err)
+	}
+	var serverErr *ServerError
+	if _, err := client.QueryScalar("SELECT COUNT(*) FROM missing"); !errors.As(err, &serverErr) {
+		t.Errorf("server error: err = %v, want *ServerError", err)
+	}
+}
+
+func TestQueryScalarT(t *testing.T) {
+	client := scalarMock(t)
+
+	n, err := QueryScalarT[int](client, "SELECT COUNT(*) FROM users")
+	if err != nil || n != 42 {
+		t.Errorf("QueryScalarT[int]() = %d, %v", n, err)
+	}
+	f, err := QueryScalarT[float64](client, "SELECT COUNT(*) FROM users")
+	if err != nil || f != 42 {
+		t.Errorf("QueryScalarT[float64]() = %v, %v", f, err)
+	}
+	s, err := QueryScalarT[string](client, "SELECT name FROM users")
+	if err != nil || s != "Alice" {
+		t.Errorf("QueryScalarT[string]() = %q, %v", s, err)
+	}
+
+	if _, err := QueryScalarT[string](client, "SELECT COUNT(*) FROM users"); err == nil {
+		t.Error("expected an error converting an Int to string")
+	}
+	if _, err := QueryScalarT[int](client, "SELECT NULL"); err == nil {
+		t.Error("expected an error converting NULL to int")
+	}
+	if p, err := QueryScalarT[*int](client, "SELECT NULL"); err != nil || p != nil {
+		t.Errorf("QueryScalarT[*int](NULL) = %v, %v", p, err)
+	}
+}
+
+func TestQueryScalarTOverflow(t *testing.T) {
+	client := scalarMock(t)
+
+	if v, err := QueryScalarT[int8](client, "SELECT COUNT(*) FROM large"); err == nil {
+		t.Errorf("QueryScalarT[int8](300) = %d, want an error", v)
+	}
+	if v, err := QueryScalarT[uint](client, "SELECT COUNT(*) FROM negative"); err == nil {
+		t.Errorf("QueryScalarT[uint](-1) = %d, want an error", v)
+	}
+	if v, err := QueryScalarT[int16](client, "SELECT COUNT(*) FROM large"); err != nil || v != 300 {
+		t.Errorf("QueryScalarT[int16](300) = %d, %v", v, err)
+	}
+}
@@ -0,0 +1,124 @@
+package poubelle
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultStatementCacheSize is the number of QueryParams templates a client
+// keeps compiled unless WithStatementCacheSize says otherwise.
+const defaultStatementCacheSize = 100
+
+// WithStatementCacheSize sets how many distinct QueryParams templates the
+// client keeps compiled, evicting the least recently used one past the
+// limit. Zero or less disables the cache. The default is 100.
+func WithStatementCacheSize(n int) Option {
+	return func(c *Client) {
+		c.stmtCacheSize = n
+	}
+}
+
+// template is a statement split around its ? placeholders, so binding
+// arguments does not rescan it. parts has one more entry than there are
+// placeholders.
+type template struct {
+	parts []string
+}
+
+func compileTemplate(sql string) *template {
+	t := &template{}
+	start := 0
+	inString := false
+	for i := 0; i < len(sql); i++ {
+		switch sql[i] {
+		case '\'':
+			inString = !inString
+		case '?':
+			if !inString {
+				t.parts = append(t.parts, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	t.parts = append(t.parts, sql[start:])
+	return t
+}
+
+func (t *template) bind(args []interface{}, style QuoteStyle) (string, error) {
+	placeholders := len(t.parts) - 1
+	if len(args) < placeholders {
+		return "", fmt.Errorf("not enough arguments: placeholder %d has no value", len(args)+1)
+	}
+	if len(args) > placeholders {
+		return "", fmt.Errorf("too many arguments: got %d, statement has %d placeholders", len(args), placeholders)
+	}
+
+	var b strings.Builder
+	b.WriteString(t.parts[0])
+	for i, arg := range args {
+		literal, err := formatLiteral(arg, style)
+		if err != nil {
+			return "", fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		b.WriteString(literal)
+		b.WriteString(t.parts[i+1])
+	}
+	return b.String(), nil
+}
+
+// statementCache is an LRU of compiled templates keyed by their SQL text.
+// Clients created by WithContext and Clone share it.
+type statementCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	sql  string
+	tmpl *template
+}
+
+// newStatementCache returns nil when size disables caching; a nil cache
+// compiles every template afresh.
+func newStatementCache(size int) *statementCache {
+	if size <= 0 {
+		return nil
+	}
+	return &statementCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (sc *statementCache) get(sql string) *template {
+	if sc == nil {
+		return compileTemplate(sql)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if e, ok := sc.items[sql]; ok {
+		sc.order.MoveToFront(e)
+		return e.Value.(*cacheEntry).tmpl
+	}
+
+	t := compileTemplate(sql)
+	sc.items[sql] = sc.order.PushFront(&cacheEntry{sql: sql, tmpl: t})
+	if sc.order.Len() > sc.size {
+		oldest := sc.order.Back()
+		sc.order.Remove(oldest)
+		delete(sc.items, oldest.Value.(*cacheEntry).sql)
+	}
+	return t
+}
+
+func (sc *statementCache) len() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.order.Len()
+}
@@ -0,0 +1,96 @@
+package poubelle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStatementCacheEviction(t *testing.T) {
+	sc := newStatementCache(2)
+	a := sc.get("SELECT * FROM a WHERE id = ?")
+	sc.get("SELECT * FROM b WHERE id = ?")
+
+	// Touch a so that b is the least recently used.
+	if sc.get("SELECT * FROM a WHERE id = ?") != a {
+		t.Error("cached template was compiled again")
+	}
+	sc.get("SELECT * FROM c WHERE id = ?")
+
+	if n := sc.len(); n != 2 {
+		t.Fatalf("cache holds %d templates, want 2", n)
+	}
+	if _, ok := sc.items["SELECT * FROM b WHERE id = ?"]; ok {
+		t.Error("least recently used template was not evicted")
+	}
+	if _, ok := sc.items["SELECT * FROM a WHERE id = ?"]; !ok {
+		t.Error("recently used template was evicted")
+	}
+}
+
+func TestStatementCacheDisabled(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "{\"id\": Int(1)}\n"
+	}, WithStatementCacheSize(0))
+	if client.stmtCache != nil {
+		t.Fatal("cache was created with size 0")
+	}
+	if _, err := client.QueryParams("SELECT * FROM t WHERE id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryParamsCached(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "{\"id\": Int(1)}\n"
+	}, WithStatementCacheSize(1))
+
+	for i := 1; i <= 3; i++ {
+		if _, err := client.QueryParams("SELECT * FROM t WHERE id = ? AND name = '?'", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.QueryParams("SELECT * FROM t WHERE id = ?", 1, 2); err == nil {
+		t.Error("expected an argument count error")
+	}
+
+	want := []string{
+		"SELECT * FROM t WHERE id = 1 AND name = '?'",
+		"SELECT * FROM t WHERE id = 2 AND name = '?'",
+		"SELECT * FROM t WHERE id = 3 AND name = '?'",
+	}
+	if got := log.all(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
+
+func benchmarkTemplate() (string, []interface{}) {
+	cols := make([]string, 50)
+	args := make([]interface{}, 50)
+	for i := range cols {
+		cols[i] = fmt.Sprintf("column_%d = ?", i)
+		args[i] = i
+	}
+	return "UPDATE wide_table SET " + strings.Join(cols, ", ") + " WHERE id = 'x'", args
+}
+
+func BenchmarkBindUncached(b *testing.B) {
+	sql, args := benchmarkTemplate()
+	for b.Loop() {
+		if _, err := bindParams(sql, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBindCached(b *testing.B) {
+	sql, args := benchmarkTemplate()
+	sc := newStatementCache(defaultStatementCacheSize)
+	for b.Loop() {
+		if _, err := sc.get(sql).bind(args, QuoteVerbatim); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSubscriptionClosed is returned by Subscription.Next after Close.
+var ErrSubscriptionClosed = errors.New("poubelle: subscription closed")
+
+// Notification is a message received on a subscribed channel.
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// Subscription receives the notifications sent on one channel. It holds a
+// connection of its own, so waiting for notifications does not block the
+// client it was created from.
+type Subscription struct {
+	client  *Client
+	channel string
+	notes   chan Notification
+	stop    chan struct{}
+	done    chan struct{}
+	err     error
+	closed  bool
+}
+
+// Subscribe opens a new connection with the configuration of c and
+// subscribes it to channel with LISTEN. Notifications are read from it in
+// the background until the subscription is closed.
+func (c *Client) Subscribe(channel string) (*Subscription, error) {
+	if !isIdentifier(channel) {
+		return nil, fmt.Errorf("invalid channel name %q", channel)
+	}
+
+	conn := c.Clone()
+	conn.ctx = c.context()
+	if err := conn.Connect(); err != nil {
+		return nil, err
+	}
+	if err := conn.Listen(channel); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.ctx = nil
+
+	s := &Subscription{
+		client:  conn,
+		channel: channel,
+		notes:   make(chan Notification),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.read()
+	return s, nil
+}
+
+// read delivers notifications until the connection fails or the
+// subscription is closed. Other lines, such as prompts and plain notices,
+// are skipped.
+func (s *Subscription) read() {
+	defer close(s.done)
+	defer close(s.notes)
+
+	reader := s.client.reader
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			select {
+			case <-s.stop:
+				s.err = ErrSubscriptionClosed
+			default:
+				s.err = fmt.Errorf("subscription to %s: %w", s.channel, err)
+			}
+			return
+		}
+
+		n, ok := parseNotice(strings.TrimSpace(strings.TrimPrefix(line, "poubelle> ")))
+		if !ok || n.Channel != s.channel {
+			continue
+		}
+		select {
+		case s.notes <- Notification{Channel: n.Channel, Payload: n.Message}:
+		case <-s.stop:
+			s.err = ErrSubscriptionClosed
+			return
+		}
+	}
+}
+
+// Next blocks until the next notification arrives, ctx is done, or the
+// subscription ends. Once the connection fails it returns that error on
+// every call, and after Close it returns ErrSubscriptionClosed.
+func (s *Subscription) Next(ctx context.Context) (Notification, error) {
+	select {
+	case n, ok := <-s.notes:
+		if !ok {
+			return Notification{}, s.err
+		}
+		return n, nil
+	case <-s.stop:
+		return Notification{}, ErrSubscriptionClosed
+	case <-ctx.Done():
+		return Notification{}, ctx.Err()
+	}
+}
+
+// Close unsubscribes with UNLISTEN and closes the subscription's
+// connection. It is safe to call more than once.
+func (s *Subscription) Close() error {
+	c := s.client
+	c.mu.Lock()
+	if s.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	if c.conn != nil {
+		c.conn.SetReadDeadline(time.Unix(1, 0))
+	}
+	c.mu.Unlock()
+	<-s.done
+
+	if c.conn == nil {
+		return c.Close()
+	}
+	c.conn.SetReadDeadline(time.Time{})
+	err := c.ExecuteDDL("UNLISTEN " + s.channel)
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package poubelle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pubsubServer accepts one connection, acknowledges LISTEN, and then writes
+// every string sent on push. Statements it receives afterwards are sent on
+// got.
+func pubsubServer(t *testing.T) (dsn string, push chan<- string, got <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	pushc := make(chan string)
+	gotc := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		if !defaultHandshake(conn, reader) {
+			return
+		}
+		fmt.Fprint(conn, "poubelle> ")
+		if line, _ := reader.ReadString('\n'); strings.TrimSpace(line) != "LISTEN jobs" {
+			fmt.Fprint(conn, "Error: expected LISTEN\npoubelle> ")
+			return
+		}
+		fmt.Fprint(conn, "OK\npoubelle> ")
+
+		go func() {
+			for msg := range pushc {
+				fmt.Fprint(conn, msg)
+			}
+		}()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			query := strings.TrimSpace(line)
+			gotc <- query
+			if query == "exit" {
+				fmt.Fprint(conn, "Goodbye\n")
+				return
+			}
+			fmt.Fprint(conn, "OK\npoubelle> ")
+		}
+	}()
+	return fmt.Sprintf("poubelle://admin:admin@%s", ln.Addr()), pushc, gotc
+}
+
+func TestSubscribe(t *testing.T) {
+	dsn, push, got := pubsubServer(t)
+	client, err := NewClient(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := client.Subscribe("jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	push <- "NOTIFY other: ignored\nNOTICE: ignored\nNOTIFY jobs: job 7 done\n"
+	push <- "NOTIFY jobs: job 8 done\n"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, want := range []string{"job 7 done", "job 8 done"} {
+		n, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Channel != "jobs" || n.Payload != want {
+			t.Errorf("Next() = %+v, want payload %q", n, want)
+		}
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := sub.Next(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Next() with no notification = %v, want DeadlineExceeded", err)
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if q := <-got; q != "UNLISTEN jobs" {
+		t.Errorf("Close sent %q, want UNLISTEN jobs", q)
+	}
+	if _, err := sub.Next(ctx); !errors.Is(err, ErrSubscriptionClosed) {
+		t.Errorf("Next() after Close = %v, want ErrSubscriptionClosed", err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+}
+
+func TestSubscribeRejectsInvalidChannel(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "OK\n" })
+
+	if _, err := client.Subscribe("jobs; DROP TABLE users"); err == nil {
+		t.Error("expected invalid channel to be rejected")
+	}
+}
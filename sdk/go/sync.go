@@ -0,0 +1,73 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// syncQuiet is how long the connection must stay silent for Sync to treat
+// it as drained.
+const syncQuiet = 50 * time.Millisecond
+
+// Sync brings the connection back to a clean prompt after an operation that
+// may have left it out of step, such as a response that was only partly
+// read. It discards whatever the server has sent, sends an empty line to
+// elicit a fresh prompt, and discards again until the server stays silent.
+// Responses to ExecuteAsync statements are discarded too.
+//
+// A compressed stream cannot be drained this way, so with compression Sync
+// reconnects instead.
+func (c *Client) Sync() error {
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ready(); err != nil {
+		return err
+	}
+	if c.compression {
+		c.resetConn()
+		return c.connect(ctx)
+	}
+
+	c.pending = 0
+	err := c.discardUntilQuiet()
+	if err == nil {
+		err = c.writeLine("")
+	}
+	if err == nil {
+		c.armReadDeadline()
+		_, err = readUntilPrompt(c.reader, "poubelle> ", 0)
+		c.clearReadDeadline()
+	}
+	if err == nil {
+		err = c.discardUntilQuiet()
+	}
+	if err != nil {
+		c.resetConn()
+		return fmt.Errorf("sync failed: %w", err)
+	}
+	return nil
+}
+
+// discardUntilQuiet drops everything the server sends until nothing more
+// arrives for syncQuiet.
+func (c *Client) discardUntilQuiet() error {
+	defer c.conn.SetReadDeadline(time.Time{})
+	for {
+		c.reader.Discard(c.reader.Buffered())
+		c.conn.SetReadDeadline(time.Now().Add(syncQuiet))
+		if _, err := c.reader.Peek(1); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package poubelle
+
+import "testing"
+
+func TestSyncRestoresProtocol(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "SELECT noisy" {
+			// A second prompt and output the client does not expect,
+			// leaving unread data behind the first prompt.
+			return "ok\npoubelle> stray output\n"
+		}
+		return "ok\n"
+	})
+
+	if _, err := client.Query("SELECT noisy"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := client.Query("SELECT 1"); got == "ok" {
+		t.Fatal("test setup did not desync the connection")
+	}
+
+	if err := client.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := client.Query("SELECT 1"); err != nil || got != "ok" {
+			t.Fatalf("Query() after Sync = %q, %v, want ok", got, err)
+		}
+	}
+}
+
+func TestSyncCleanConnection(t *testing.T) {
+	client := connectMock(t, func(query string) string { return "ok\n" })
+
+	if err := client.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := client.Query("SELECT 1"); err != nil || got != "ok" {
+		t.Errorf("Query() = %q, %v", got, err)
+	}
+}
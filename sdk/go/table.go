@@ -0,0 +1,184 @@
+package poubelle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResultSet is a query result with its column names in server order.
+type ResultSet struct {
+	Columns []string
+	// ColumnTypes holds the type of each column, such as INT or TEXT.
+	// The server does not describe result columns, so the types are
+	// inferred from the first non-null value of each column, or empty if
+	// every value is null, until ApplyColumnInfo sets declared ones.
+	ColumnTypes []string
+	// TypeInferred reports, per column, whether ColumnTypes was inferred
+	// rather than declared.
+	TypeInferred []bool
+	Rows         []Row
+}
+
+// ApplyColumnInfo replaces the inferred type of every column named in cols,
+// as returned by DescribeTable, with its declared type.
+func (rs *ResultSet) ApplyColumnInfo(cols []ColumnInfo) {
+	for _, col := range cols {
+		for i, name := range rs.Columns {
+			if name == col.Name {
+				rs.ColumnTypes[i] = col.Type
+				rs.TypeInferred[i] = false
+			}
+		}
+	}
+}
+
+// inferColumnTypes sets the column types from the first non-null value of
+// each column.
+func (rs *ResultSet) inferColumnTypes() {
+	rs.ColumnTypes = make([]string, len(rs.Columns))
+	rs.TypeInferred = make([]bool, len(rs.Columns))
+	for i, col := range rs.Columns {
+		rs.TypeInferred[i] = true
+		for _, row := range rs.Rows {
+			if typ := valueType(row[col]); typ != "" {
+				rs.ColumnTypes[i] = typ
+				break
+			}
+		}
+	}
+}
+
+// valueType returns the server type name for a parsed value, or "" for
+// NULL.
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return "INT"
+	default:
+		return "TEXT"
+	}
+}
+
+// ExecuteTable runs sql with FORMAT TABLE and parses the aligned table the
+// server returns: a header row, a separator line of dashes, then one line
+// per row. Columns are delimited by "|" when the table uses it, and by the
+// dash runs of the separator line otherwise, so values may contain spaces.
+// Cells that parse as integers become int64, NULL becomes nil, and anything
+// else is a string.
+func (c *Client) ExecuteTable(sql string, args ...interface{}) (*ResultSet, error) {
+	if !strings.Contains(strings.ToUpper(sql), "FORMAT TABLE") {
+		sql = sql + " FORMAT TABLE"
+	}
+
+	result, err := c.queryArgs(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTable(result)
+}
+
+func parseTable(result string) (*ResultSet, error) {
+	if result == "" || result == "No rows" {
+		return &ResultSet{Rows: []Row{}}, nil
+	}
+
+	lines := strings.Split(result, "\n")
+	if len(lines) < 2 || !isTableSeparator(lines[1]) {
+		return nil, fmt.Errorf("response is not an aligned table: %q", result)
+	}
+
+	split := splitBySpans(tableSpans(lines[1]))
+	if strings.Contains(lines[0], "|") {
+		split = splitByPipe
+	}
+
+	rs := &ResultSet{Columns: split(lines[0]), Rows: []Row{}}
+	for _, line := range lines[2:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cells := split(line)
+		if len(cells) != len(rs.Columns) {
+			return nil, fmt.Errorf("table row has %d cells, header has %d: %q", len(cells), len(rs.Columns), line)
+		}
+		row := make(Row, len(cells))
+		for i, cell := range cells {
+			row[rs.Columns[i]] = parseTableCell(cell)
+		}
+		rs.Rows = append(rs.Rows, row)
+	}
+
+	rs.inferColumnTypes()
+	return rs, nil
+}
+
+func isTableSeparator(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.Trim(line, "-+| ") == "" && strings.Contains(line, "-")
+}
+
+// tableSpans returns the [start, end) byte ranges of the dash runs in a
+// separator line.
+func tableSpans(separator string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i := 0; i <= len(separator); i++ {
+		dash := i < len(separator) && separator[i] == '-'
+		switch {
+		case dash && start < 0:
+			start = i
+		case !dash && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	return spans
+}
+
+func splitBySpans(spans [][2]int) func(string) []string {
+	return func(line string) []string {
+		cells := make([]string, len(spans))
+		for i, span := range spans {
+			start, end := span[0], span[1]
+			if i == len(spans)-1 {
+				end = len(line)
+			} else if next := spans[i+1][0]; end < next {
+				end = next
+			}
+			if start > len(line) {
+				start = len(line)
+			}
+			if end > len(line) {
+				end = len(line)
+			}
+			cells[i] = strings.TrimSpace(line[start:end])
+		}
+		return cells
+	}
+}
+
+func splitByPipe(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func parseTableCell(cell string) interface{} {
+	if cell == "NULL" {
+		return nil
+	}
+	if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return n
+	}
+	return cell
+}
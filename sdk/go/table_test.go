@@ -0,0 +1,95 @@
+package poubelle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecuteTable(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return " id | name        | email\n" +
+			"----+-------------+------------------\n" +
+			" 1  | Alice Smith | alice@example.com\n" +
+			" 2  | Bob         | NULL\n"
+	})
+
+	rs, err := client.ExecuteTable("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rs.Columns, []string{"id", "name", "email"}) {
+		t.Errorf("columns = %q", rs.Columns)
+	}
+	want := []Row{
+		{"id": int64(1), "name": "Alice Smith", "email": "alice@example.com"},
+		{"id": int64(2), "name": "Bob", "email": nil},
+	}
+	if !RowsEqual(rs.Rows, want) {
+		t.Errorf("rows differ:\n%s", DiffRows(want, rs.Rows))
+	}
+}
+
+func TestParseTableWithoutPipes(t *testing.T) {
+	rs, err := parseTable("id  name         city\n" +
+		"--  -----------  ---------\n" +
+		"1   Alice Smith  Oslo\n" +
+		"2   Bob          New York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Row{
+		{"id": int64(1), "name": "Alice Smith", "city": "Oslo"},
+		{"id": int64(2), "name": "Bob", "city": "New York"},
+	}
+	if !RowsEqual(rs.Rows, want) {
+		t.Errorf("rows differ:\n%s", DiffRows(want, rs.Rows))
+	}
+}
+
+func TestParseTableRejectsOtherFormats(t *testing.T) {
+	if _, err := parseTable(`{"id": Int(1)}`); err == nil {
+		t.Error("expected debug-format output to be rejected")
+	}
+	if rs, err := parseTable("No rows"); err != nil || len(rs.Rows) != 0 {
+		t.Errorf("parseTable(No rows) = %v, %v", rs, err)
+	}
+}
+
+func TestExecuteTableColumnTypes(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "DESCRIBE users" {
+			return `{"name": Text("id"), "type": Text("INT")}` + "\n" +
+				`{"name": Text("name"), "type": Text("TEXT")}` + "\n" +
+				`{"name": Text("note"), "type": Text("TEXT")}` + "\n"
+		}
+		return " id | name  | note | code\n" +
+			"----+-------+------+------\n" +
+			" 1  | Alice | NULL | 7\n" +
+			" 2  | 42    | NULL | x\n"
+	})
+
+	rs, err := client.ExecuteTable("SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"INT", "TEXT", "", "INT"}; !reflect.DeepEqual(rs.ColumnTypes, want) {
+		t.Errorf("inferred types = %q, want %q", rs.ColumnTypes, want)
+	}
+	if want := []bool{true, true, true, true}; !reflect.DeepEqual(rs.TypeInferred, want) {
+		t.Errorf("TypeInferred = %v, want %v", rs.TypeInferred, want)
+	}
+
+	cols, err := client.DescribeTable("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.ApplyColumnInfo(cols)
+	if want := []string{"INT", "TEXT", "TEXT", "INT"}; !reflect.DeepEqual(rs.ColumnTypes, want) {
+		t.Errorf("declared types = %q, want %q", rs.ColumnTypes, want)
+	}
+	if want := []bool{false, false, false, true}; !reflect.DeepEqual(rs.TypeInferred, want) {
+		t.Errorf("TypeInferred = %v, want %v", rs.TypeInferred, want)
+	}
+}
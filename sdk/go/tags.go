@@ -0,0 +1,43 @@
+package poubelle
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// QueryTagged runs sql with tags prepended as a /* key='value',... */
+// comment, so server logs can be correlated with client-side traces. Keys
+// are sorted, and keys and values are URL-encoded, which leaves no "*/",
+// quote or line break that could end the comment or the statement early.
+//
+// The shipped Poubelle lexer has no comment syntax and rejects the tagged
+// statement, so tags need a server that accepts /* */ comments. Once the
+// server is known not to, from its banner or Capabilities, QueryTagged
+// returns ErrUnsupported without sending anything.
+func (c *Client) QueryTagged(sql string, tags map[string]string) (string, error) {
+	if len(tags) > 0 {
+		if err := c.checkFeature(featureComments); err != nil {
+			return "", err
+		}
+	}
+	return c.Query(tagComment(tags) + sql)
+}
+
+func tagComment(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = url.QueryEscape(k) + "='" + url.QueryEscape(tags[k]) + "'"
+	}
+	return "/* " + strings.Join(pairs, ",") + " */ "
+}
@@ -0,0 +1,48 @@
+package poubelle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueryTagged(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "OK\n"
+	})
+
+	_, err := client.QueryTagged("SELECT * FROM users", map[string]string{
+		"trace_id": "abc123",
+		"app":      "billing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/* app='billing',trace_id='abc123' */ SELECT * FROM users"
+	if got := log.all()[0]; got != want {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestTagCommentEscaping(t *testing.T) {
+	comment := tagComment(map[string]string{
+		"route*/": "x */ DROP TABLE users; /*",
+		"quote":   "it's\nmultiline",
+	})
+
+	body := strings.TrimSuffix(strings.TrimPrefix(comment, "/* "), " */ ")
+	for _, bad := range []string{"*/", "/*", "\n", "\r", "''"} {
+		if strings.Contains(body, bad) {
+			t.Errorf("comment body %q contains %q", body, bad)
+		}
+	}
+	if strings.Count(body, "'") != 4 {
+		t.Errorf("comment body %q has unescaped quotes", body)
+	}
+
+	if tagComment(nil) != "" {
+		t.Error("empty tags should add no comment")
+	}
+}
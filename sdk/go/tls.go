@@ -0,0 +1,81 @@
+package poubelle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCertPinMismatch is returned by Connect when the server's certificate
+// does not match the pin set with WithServerCertPin.
+var ErrCertPinMismatch = errors.New("server certificate does not match pin")
+
+// WithTLSConfig sets the TLS configuration used for poubelles://
+// connections. ServerName defaults to the host being connected to.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithTLSPreferred makes Connect try TLS first even for a poubelle://
+// connection string, and reconnect in plaintext to the same address if the
+// server does not complete the TLS handshake. The mode that was used is
+// reported to the logger. With a certificate pin there is no fallback.
+func WithTLSPreferred() Option {
+	return func(c *Client) {
+		c.tlsPreferred = true
+	}
+}
+
+// WithServerCertPin pins the server's leaf certificate to the given SHA-256
+// fingerprint, in hex with optional colons. The pin replaces CA
+// verification: the connection succeeds only if the fingerprint matches,
+// whoever signed the certificate. Other settings from WithTLSConfig still
+// apply. The pin requires a poubelles:// connection string.
+func WithServerCertPin(sha256Fingerprint string) Option {
+	return func(c *Client) {
+		c.certPin = sha256Fingerprint
+	}
+}
+
+func (c *Client) clientTLSConfig(serverName string) *tls.Config {
+	var cfg *tls.Config
+	if c.tlsConfig != nil {
+		cfg = c.tlsConfig.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = serverName
+	}
+
+	if c.certPin != "" {
+		pin := c.certPin
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyCertPin(cs, pin)
+		}
+	}
+	return cfg
+}
+
+func verifyCertPin(cs tls.ConnectionState, pin string) error {
+	want, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(pin), ":", ""))
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("invalid certificate pin %q: want a hex SHA-256 fingerprint", pin)
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return ErrCertPinMismatch
+	}
+
+	got := sha256.Sum256(cs.PeerCertificates[0].Raw)
+	if !bytes.Equal(got[:], want) {
+		return fmt.Errorf("%w: got %s", ErrCertPinMismatch, hex.EncodeToString(got[:]))
+	}
+	return nil
+}
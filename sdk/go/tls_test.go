@@ -0,0 +1,189 @@
+package poubelle
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selfSignedCert returns a certificate for 127.0.0.1 and its SHA-256
+// fingerprint.
+func selfSignedCert(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "poubelle test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, hex.EncodeToString(sum[:])
+}
+
+func newTLSMockServer(t *testing.T) (*mockServer, string) {
+	cert, fingerprint := selfSignedCert(t)
+	s := &mockServer{
+		handle:    func(query string) string { return "ok\n" },
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	s.start(t)
+	return s, fingerprint
+}
+
+func TestServerCertPin(t *testing.T) {
+	s, fingerprint := newTLSMockServer(t)
+
+	// Colon-separated uppercase pins are accepted too.
+	var colons []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		colons = append(colons, strings.ToUpper(fingerprint[i:i+2]))
+	}
+
+	for _, pin := range []string{fingerprint, strings.Join(colons, ":")} {
+		client, err := NewClient(s.dsn(), WithServerCertPin(pin))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Connect(); err != nil {
+			t.Fatalf("Connect() with matching pin error = %v", err)
+		}
+		if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+			t.Errorf("Query() = %q, %v", result, err)
+		}
+		client.Close()
+	}
+}
+
+func TestServerCertPinMismatch(t *testing.T) {
+	s, _ := newTLSMockServer(t)
+
+	client, err := NewClient(s.dsn(), WithServerCertPin(strings.Repeat("ab", sha256.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); !errors.Is(err, ErrCertPinMismatch) {
+		t.Errorf("Connect() error = %v, want ErrCertPinMismatch", err)
+	}
+	if client.connected() {
+		t.Error("client connected despite pin mismatch")
+	}
+}
+
+func TestTLSConfigWithoutPin(t *testing.T) {
+	s, _ := newTLSMockServer(t)
+
+	// The self-signed certificate is not trusted without a pin.
+	client, err := NewClient(s.dsn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err == nil {
+		client.Close()
+		t.Fatal("Connect() trusted a self-signed certificate")
+	}
+
+	client, err = NewClient(s.dsn(), WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+}
+
+func TestServerCertPinRequiresTLS(t *testing.T) {
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+
+	client, err := NewClient(s.dsn(), WithServerCertPin(strings.Repeat("ab", sha256.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err == nil {
+		client.Close()
+		t.Error("Connect() ignored the pin on a plaintext connection")
+	}
+}
+
+func TestTLSPreferred(t *testing.T) {
+	tlsServer, _ := newTLSMockServer(t)
+	plainServer := newMockServer(t, func(query string) string { return "ok\n" })
+
+	tests := []struct {
+		name    string
+		dsn     string
+		wantTLS bool
+	}{
+		{"tls server", strings.Replace(tlsServer.dsn(), "poubelles://", "poubelle://", 1), true},
+		{"plaintext server", plainServer.dsn(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			client, err := NewClient(tt.dsn,
+				WithTLSPreferred(),
+				WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+				WithLogger(log.New(&logs, "", 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := client.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if _, isTLS := client.conn.(*tls.Conn); isTLS != tt.wantTLS {
+				t.Errorf("TLS = %v, want %v", isTLS, tt.wantTLS)
+			}
+			if result, err := client.Query("SELECT 1"); err != nil || result != "ok" {
+				t.Errorf("Query() = %q, %v", result, err)
+			}
+
+			want := "with TLS"
+			if !tt.wantTLS {
+				want = "connecting without TLS"
+			}
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("log %q does not mention %q", logs.String(), want)
+			}
+		})
+	}
+}
+
+func TestTLSPreferredNoFallbackWithPin(t *testing.T) {
+	s := newMockServer(t, func(query string) string { return "ok\n" })
+
+	client, err := NewClient(s.dsn(), WithTLSPreferred(), WithServerCertPin(strings.Repeat("ab", sha256.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err == nil {
+		client.Close()
+		t.Error("Connect() fell back to plaintext despite a certificate pin")
+	}
+}
@@ -0,0 +1,42 @@
+package poubelle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// traceConn writes a hex dump of every chunk read from or written to the
+// underlying connection. The bytes passed through are left untouched.
+type traceConn struct {
+	net.Conn
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *traceConn) Read(p []byte) (int, error) {
+	n, err := t.Conn.Read(p)
+	if n > 0 {
+		t.dump("recv", p[:n])
+	}
+	return n, err
+}
+
+func (t *traceConn) Write(p []byte) (int, error) {
+	n, err := t.Conn.Write(p)
+	if n > 0 {
+		t.dump("send", p[:n])
+	}
+	return n, err
+}
+
+func (t *traceConn) dump(direction string, p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "%s %s %d bytes\n%s", time.Now().Format(time.RFC3339Nano), direction, len(p), hex.Dump(p))
+}
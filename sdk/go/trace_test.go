@@ -0,0 +1,51 @@
+package poubelle
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWireTrace(t *testing.T) {
+	trace := &syncBuffer{}
+	client := connectMock(t, func(query string) string {
+		return "Row\tinserted\n"
+	}, WithWireTrace(trace))
+
+	result, err := client.Query("INSERT INTO t (a) VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "Row\tinserted" {
+		t.Errorf("tracing altered the response: %q", result)
+	}
+
+	out := trace.String()
+	if !strings.Contains(out, " send ") || !strings.Contains(out, " recv ") {
+		t.Errorf("trace is missing a direction annotation:\n%s", out)
+	}
+	if !strings.Contains(out, "49 4e 53 45") {
+		t.Errorf("trace does not contain the sent statement:\n%s", out)
+	}
+	if !strings.Contains(out, "09") {
+		t.Errorf("trace does not show the tab control byte:\n%s", out)
+	}
+}
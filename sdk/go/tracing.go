@@ -0,0 +1,86 @@
+package poubelle
+
+import (
+	"context"
+	"strings"
+)
+
+// Tracer starts spans for statements. It is a subset of OpenTelemetry's
+// trace.Tracer, so an adapter of a few lines connects the two without the
+// SDK depending on OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the part of a tracing span the client uses.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// maxTracedStatement is the longest statement recorded on a span.
+const maxTracedStatement = 1024
+
+// WithTracer wraps every statement in a span named poubelle.query, a child
+// of the client's context. The span records the statement with literals
+// replaced by ?, the number of rows returned, and any error, including an
+// error acknowledgment from the server.
+func WithTracer(t Tracer) Option {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+func (c *Client) tracedQuery(ctx context.Context, sql string, raw bool, each func(line string) error) (string, error) {
+	ctx, span := c.tracer.Start(ctx, "poubelle.query")
+	defer span.End()
+
+	span.SetAttribute("db.system", "poubelle")
+	span.SetAttribute("db.statement", sanitizeStatement(sql))
+
+	result, err := c.runQuery(ctx, sql, raw, each)
+	if err == nil && each == nil {
+		err = ackError(result)
+		span.SetAttribute("db.rows", countRows(result))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	return result, err
+}
+
+// sanitizeStatement replaces string literals in sql with ? and truncates it,
+// so spans carry the shape of a statement but not its data.
+func sanitizeStatement(sql string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case ch == '\'':
+			if !inString {
+				b.WriteByte('?')
+			}
+			inString = !inString
+		case !inString:
+			b.WriteByte(ch)
+		}
+	}
+
+	s := b.String()
+	if len(s) > maxTracedStatement {
+		s = s[:maxTracedStatement] + "..."
+	}
+	return s
+}
+
+func countRows(result string) int {
+	n := 0
+	for line := range recordLines(result) {
+		if isRecord(line) {
+			n++
+		}
+	}
+	return n
+}
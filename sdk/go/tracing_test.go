@@ -0,0 +1,90 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordedSpan struct {
+	name   string
+	parent context.Context
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordedSpan) RecordError(err error)                      { s.err = err }
+func (s *recordedSpan) End()                                       { s.ended = true }
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordedSpan{name: name, parent: ctx, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+type ctxKey struct{}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	client := connectMock(t, func(query string) string {
+		if strings.HasPrefix(query, "SELECT") {
+			return `{"id": Int(1)}` + "\n" + `{"id": Int(2)}` + "\n"
+		}
+		return "Error: Table missing not found\n"
+	}, WithTracer(tracer))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "parent")
+	if _, err := client.WithContext(ctx).Execute("SELECT * FROM users WHERE name = 'Alice'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ExecuteDDL("INSERT INTO missing (id) VALUES (1)"); err == nil {
+		t.Fatal("expected server error")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(tracer.spans))
+	}
+
+	read := tracer.spans[0]
+	if read.name != "poubelle.query" || !read.ended {
+		t.Errorf("span = %+v", read)
+	}
+	if read.parent.Value(ctxKey{}) != "parent" {
+		t.Error("span was not started from the client's context")
+	}
+	if got := read.attrs["db.statement"]; got != "SELECT * FROM users WHERE name = ?" {
+		t.Errorf("db.statement = %q", got)
+	}
+	if got := read.attrs["db.rows"]; got != 2 {
+		t.Errorf("db.rows = %v, want 2", got)
+	}
+	if read.err != nil {
+		t.Errorf("unexpected span error %v", read.err)
+	}
+
+	var serverErr *ServerError
+	if write := tracer.spans[1]; !errors.As(write.err, &serverErr) {
+		t.Errorf("span error = %v, want *ServerError", write.err)
+	}
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	if got := sanitizeStatement("INSERT INTO t VALUES ('secret', 1, 'x')"); got != "INSERT INTO t VALUES (?, 1, ?)" {
+		t.Errorf("sanitizeStatement() = %q", got)
+	}
+	long := sanitizeStatement(strings.Repeat("a", maxTracedStatement+10))
+	if len(long) != maxTracedStatement+3 || !strings.HasSuffix(long, "...") {
+		t.Errorf("long statement not truncated: %d bytes", len(long))
+	}
+}
@@ -0,0 +1,294 @@
+package poubelle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrTxDone is returned by operations on a transaction that has already
+// been committed or rolled back.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Tx is a transaction on a client's connection. The connection is held by
+// the transaction, and other operations on the client wait, until Commit or
+// Rollback is called.
+type Tx struct {
+	c    *Client
+	ctx  context.Context
+	done bool
+
+	// onDone runs once the connection has been released, for transactions
+	// on a pooled connection.
+	onDone func()
+}
+
+// IsolationLevel is a transaction isolation level for WithIsolation.
+type IsolationLevel int
+
+const (
+	// IsolationDefault leaves the isolation level to the server.
+	IsolationDefault IsolationLevel = iota
+	ReadUncommitted
+	ReadCommitted
+	RepeatableRead
+	Serializable
+)
+
+// String returns the level as written in BEGIN ISOLATION LEVEL.
+func (l IsolationLevel) String() string {
+	switch l {
+	case IsolationDefault:
+		return "DEFAULT"
+	case ReadUncommitted:
+		return "READ UNCOMMITTED"
+	case ReadCommitted:
+		return "READ COMMITTED"
+	case RepeatableRead:
+		return "REPEATABLE READ"
+	case Serializable:
+		return "SERIALIZABLE"
+	}
+	return fmt.Sprintf("IsolationLevel(%d)", int(l))
+}
+
+// TxOption configures a transaction started with Begin.
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	isolation IsolationLevel
+	readOnly  bool
+}
+
+// WithIsolation starts the transaction at the given isolation level.
+func WithIsolation(level IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.isolation = level
+	}
+}
+
+// WithReadOnly starts a read-only transaction, in which the server rejects
+// writes.
+func WithReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.readOnly = true
+	}
+}
+
+// beginStatement returns the BEGIN statement for opts, or an error for an
+// isolation level the server does not have.
+func beginStatement(opts []TxOption) (string, error) {
+	var o txOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	stmt := "BEGIN"
+	switch o.isolation {
+	case IsolationDefault:
+	case ReadUncommitted, ReadCommitted, RepeatableRead, Serializable:
+		stmt += " ISOLATION LEVEL " + o.isolation.String()
+	default:
+		return "", fmt.Errorf("unsupported isolation level %v", o.isolation)
+	}
+	if o.readOnly {
+		stmt += " READ ONLY"
+	}
+	return stmt, nil
+}
+
+// Begin starts a transaction with BEGIN, followed by ISOLATION LEVEL and
+// READ ONLY as the options ask. An unsupported isolation level fails
+// before anything is sent.
+func (c *Client) Begin(opts ...TxOption) (*Tx, error) {
+	stmt, err := beginStatement(opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if err := c.requireFeature(featureTransactions); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	if err := c.exec(ctx, stmt); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	return &Tx{c: c, ctx: ctx}, nil
+}
+
+// Query runs sql inside the transaction and returns the raw result. Any
+// args are bound to its ? placeholders as in QueryParams.
+func (tx *Tx) Query(sql string, args ...interface{}) (string, error) {
+	if tx.done {
+		return "", ErrTxDone
+	}
+	stmt, err := tx.c.bindArgs(sql, args)
+	if err != nil {
+		return "", err
+	}
+	return tx.c.query(tx.ctx, stmt)
+}
+
+// Execute runs sql inside the transaction and parses the rows.
+func (tx *Tx) Execute(sql string, args ...interface{}) ([]Row, error) {
+	result, err := tx.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return tx.c.decodeRows(result)
+}
+
+// ExecuteDDL runs a statement inside the transaction and interprets its
+// acknowledgment like Client.ExecuteDDL.
+func (tx *Tx) ExecuteDDL(sql string, args ...interface{}) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	stmt, err := tx.c.bindArgs(sql, args)
+	if err != nil {
+		return err
+	}
+	return tx.c.exec(tx.ctx, stmt)
+}
+
+// Commit commits the transaction and releases the connection.
+func (tx *Tx) Commit() error {
+	return tx.finish("COMMIT")
+}
+
+// Rollback aborts the transaction and releases the connection.
+func (tx *Tx) Rollback() error {
+	return tx.finish("ROLLBACK")
+}
+
+func (tx *Tx) finish(stmt string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	err := tx.c.exec(tx.ctx, stmt)
+	tx.c.mu.Unlock()
+
+	if tx.onDone != nil {
+		tx.onDone()
+	}
+	return err
+}
+
+// exec runs stmt with c.mu held and turns an error acknowledgment into a
+// *ServerError.
+func (c *Client) exec(ctx context.Context, stmt string) error {
+	result, err := c.query(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	return ackError(result)
+}
+
+// queryInTransaction runs a single write between BEGIN and COMMIT, with
+// c.mu held, for WithAutoTransaction. If the write is rejected the
+// transaction is rolled back and the rejection returned as the result, as
+// it would be without the transaction.
+func (c *Client) queryInTransaction(ctx context.Context, sql string) (string, error) {
+	if err := c.exec(ctx, "BEGIN"); err != nil {
+		return "", err
+	}
+
+	result, err := c.query(ctx, sql)
+	if err != nil || ackError(result) != nil {
+		if c.conn != nil {
+			if rerr := c.exec(ctx, "ROLLBACK"); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+		return result, err
+	}
+
+	if err := c.exec(ctx, "COMMIT"); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// RetryOpts controls how WithTransaction retries transient conflicts.
+type RetryOpts struct {
+	// MaxRetries is the number of extra attempts after the first.
+	MaxRetries int
+	// Backoff is the longest wait before each retry; see WithBackoffJitter.
+	Backoff time.Duration
+}
+
+// WithTransaction runs fn in a transaction, committing if it returns nil and
+// rolling back otherwise. If fn or the commit fails with a transient
+// conflict reported by the server, such as a deadlock or serialization
+// failure, the whole transaction is retried up to opts.MaxRetries times.
+// Other errors are returned immediately.
+func (c *Client) WithTransaction(fn func(*Tx) error, opts RetryOpts) error {
+	ctx := c.context()
+	for attempt := 0; ; attempt++ {
+		err := c.runTransaction(fn)
+		if err == nil || !isTransientConflict(err) || attempt >= opts.MaxRetries {
+			return err
+		}
+
+		if opts.Backoff > 0 {
+			if err := c.sleep(ctx, c.jitter(opts.Backoff)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Client) runTransaction(fn func(*Tx) error) error {
+	tx, err := c.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !tx.done {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// transientMarkers are fragments of server error messages, lowercased, that
+// mean a transaction lost a conflict and may succeed if run again. They
+// include the SQLSTATE codes for serialization failure and deadlock.
+var transientMarkers = []string{
+	"deadlock",
+	"serialization failure",
+	"could not serialize",
+	"write conflict",
+	"40001",
+	"40p01",
+}
+
+func isTransientConflict(err error) bool {
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+
+	msg := strings.ToLower(serverErr.Message)
+	for _, marker := range transientMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
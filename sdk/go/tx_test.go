@@ -0,0 +1,252 @@
+package poubelle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTxCommitAndRollback(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "OK\n"
+	})
+
+	tx, err := client.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ExecuteDDL("INSERT INTO users (id) VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Rollback() after Commit error = %v, want ErrTxDone", err)
+	}
+
+	// The connection must be released again.
+	if _, err := client.Query("SELECT * FROM users"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"BEGIN", "INSERT INTO users (id) VALUES (1)", "COMMIT", "SELECT * FROM users"}
+	if got := log.all(); !reflect.DeepEqual(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
+
+func TestTxArgs(t *testing.T) {
+	var log queryLog
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if strings.HasPrefix(query, "SELECT") {
+			return "{\"id\": Int(1)}\n"
+		}
+		return "OK\n"
+	})
+
+	const sql = "SELECT * FROM users WHERE name = ?"
+	const bound = "SELECT * FROM users WHERE name = 'Alice'"
+
+	tx, err := client.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	tests := []struct {
+		name string
+		run  func(args ...interface{}) error
+	}{
+		{"Query", func(args ...interface{}) error { _, err := tx.Query(sql, args...); return err }},
+		{"Execute", func(args ...interface{}) error { _, err := tx.Execute(sql, args...); return err }},
+		{"ExecuteDDL", func(args ...interface{}) error { return tx.ExecuteDDL(sql, args...) }},
+	}
+	for _, tt := range tests {
+		log = queryLog{}
+		if err := tt.run("Alice"); err != nil {
+			t.Errorf("%s with args: %v", tt.name, err)
+		}
+		// Without args the statement is sent as is, placeholder included.
+		if err := tt.run(); err != nil {
+			t.Errorf("%s without args: %v", tt.name, err)
+		}
+		if err := tt.run("Alice", "Bob"); err == nil {
+			t.Errorf("%s with too many args: expected error", tt.name)
+		}
+
+		if got, want := log.all(), []string{bound, sql}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s sent %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestWithTransactionRetriesTransientConflict(t *testing.T) {
+	log := &queryLog{}
+	inserts := 0
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if query == "INSERT INTO users (id) VALUES (1)" {
+			inserts++
+			if inserts == 1 {
+				return "Error: deadlock detected\n"
+			}
+		}
+		return "OK\n"
+	})
+
+	attempts := 0
+	err := client.WithTransaction(func(tx *Tx) error {
+		attempts++
+		return tx.ExecuteDDL("INSERT INTO users (id) VALUES (1)")
+	}, RetryOpts{MaxRetries: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	want := []string{
+		"BEGIN", "INSERT INTO users (id) VALUES (1)", "ROLLBACK",
+		"BEGIN", "INSERT INTO users (id) VALUES (1)", "COMMIT",
+	}
+	if got := log.all(); !reflect.DeepEqual(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
+
+func TestWithTransactionNonTransient(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "INSERT INTO missing (id) VALUES (1)" {
+			return "Error: Table missing not found\n"
+		}
+		return "OK\n"
+	})
+
+	attempts := 0
+	err := client.WithTransaction(func(tx *Tx) error {
+		attempts++
+		return tx.ExecuteDDL("INSERT INTO missing (id) VALUES (1)")
+	}, RetryOpts{MaxRetries: 3})
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("WithTransaction() error = %v, want *ServerError", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestWithTransactionGivesUp(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		if query == "COMMIT" {
+			return "Error: could not serialize access due to concurrent update\n"
+		}
+		return "OK\n"
+	})
+
+	attempts := 0
+	err := client.WithTransaction(func(tx *Tx) error {
+		attempts++
+		return nil
+	}, RetryOpts{MaxRetries: 2})
+	if !isTransientConflict(err) {
+		t.Errorf("WithTransaction() error = %v, want transient conflict", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestAutoTransaction(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		if query == "INSERT INTO users (id) VALUES (2)" {
+			return "Error: duplicate key\n"
+		}
+		return "OK\n"
+	}, WithAutoTransaction(true))
+
+	if err := client.ExecuteDDL("INSERT INTO users (id) VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	var serverErr *ServerError
+	if err := client.ExecuteDDL("INSERT INTO users (id) VALUES (2)"); !errors.As(err, &serverErr) {
+		t.Errorf("failing write err = %v, want *ServerError", err)
+	}
+	if _, err := client.Execute("SELECT * FROM users"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := client.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ExecuteDDL("INSERT INTO users (id) VALUES (3)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"BEGIN", "INSERT INTO users (id) VALUES (1)", "COMMIT",
+		"BEGIN", "INSERT INTO users (id) VALUES (2)", "ROLLBACK",
+		"SELECT * FROM users",
+		"BEGIN", "INSERT INTO users (id) VALUES (3)", "COMMIT",
+	}
+	if got := log.all(); !reflect.DeepEqual(got, want) {
+		t.Errorf("queries = %q\nwant      %q", got, want)
+	}
+}
+
+func TestBeginOptions(t *testing.T) {
+	log := &queryLog{}
+	client := connectMock(t, func(query string) string {
+		log.record(query)
+		return "OK\n"
+	})
+
+	tests := []struct {
+		opts []TxOption
+		want string
+	}{
+		{nil, "BEGIN"},
+		{[]TxOption{WithIsolation(IsolationDefault)}, "BEGIN"},
+		{[]TxOption{WithIsolation(ReadUncommitted)}, "BEGIN ISOLATION LEVEL READ UNCOMMITTED"},
+		{[]TxOption{WithIsolation(ReadCommitted)}, "BEGIN ISOLATION LEVEL READ COMMITTED"},
+		{[]TxOption{WithIsolation(RepeatableRead)}, "BEGIN ISOLATION LEVEL REPEATABLE READ"},
+		{[]TxOption{WithIsolation(Serializable)}, "BEGIN ISOLATION LEVEL SERIALIZABLE"},
+		{[]TxOption{WithReadOnly()}, "BEGIN READ ONLY"},
+		{[]TxOption{WithReadOnly(), WithIsolation(Serializable)}, "BEGIN ISOLATION LEVEL SERIALIZABLE READ ONLY"},
+	}
+	for _, tt := range tests {
+		tx, err := client.Begin(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		if got := log.all(); got[len(got)-2] != tt.want {
+			t.Errorf("sent %q, want %q", got[len(got)-2], tt.want)
+		}
+	}
+
+	sent := len(log.all())
+	if _, err := client.Begin(WithIsolation(IsolationLevel(42))); err == nil || err.Error() != "unsupported isolation level IsolationLevel(42)" {
+		t.Errorf("Begin with an unknown level error = %v", err)
+	}
+	if len(log.all()) != sent {
+		t.Error("Begin sent a statement for an unsupported level")
+	}
+	if _, err := client.Query("SELECT 1"); err != nil {
+		t.Errorf("connection not released after a rejected Begin: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package poubelle
+
+import "testing"
+
+func TestParseUUID(t *testing.T) {
+	u, err := ParseUUID("550E8400-e29b-41d4-a716-446655440000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u[0] != 0x55 || u[15] != 0x00 || u[6] != 0x41 {
+		t.Errorf("ParseUUID() = %x", u)
+	}
+	if got := u.String(); got != "550e8400-e29b-41d4-a716-446655440000" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, s := range []string{
+		"",
+		"550e8400e29b41d4a716446655440000",
+		"550e8400-e29b-41d4-a716-44665544000",
+		"550e8400-e29b-41d4-a716-4466554400000",
+		"550e8400-e29b-41d4-a716_446655440000",
+		"g50e8400-e29b-41d4-a716-446655440000",
+	} {
+		if _, err := ParseUUID(s); err == nil {
+			t.Errorf("ParseUUID(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestParseValueUUID(t *testing.T) {
+	v := parseTypedValue("Uuid(550E8400-E29B-41D4-A716-446655440000)")
+	if v.Kind != KindUUID {
+		t.Fatalf("Kind = %v, want Uuid", v.Kind)
+	}
+	if got := parseValue("Uuid(550E8400-E29B-41D4-A716-446655440000)"); got != "550e8400-e29b-41d4-a716-446655440000" {
+		t.Errorf("parseValue() = %#v", got)
+	}
+
+	for _, raw := range []string{"Uuid(not-a-uuid)", "Uuid()"} {
+		v := parseTypedValue(raw)
+		if v.Kind != KindRaw || v.Text != raw {
+			t.Errorf("parseTypedValue(%s) = %+v, want raw fallback", raw, v)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package poubelle
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValueKind identifies the server type a value was decoded from.
+type ValueKind int
+
+const (
+	// KindRaw marks a value the SDK did not recognize. Its source text is
+	// kept in TypedValue.Text.
+	KindRaw ValueKind = iota
+	KindNull
+	KindInt
+	KindText
+	KindList
+	KindUUID
+	// KindBigInt marks an integer outside the int64 range, from BigInt(...)
+	// or an overflowing Int(...). The value is in TypedValue.Big.
+	KindBigInt
+	// KindCustom marks a value decoded by a parser added with
+	// RegisterValueParser. The result is in TypedValue.Custom.
+	KindCustom
+	KindFloat
+)
+
+func (k ValueKind) String() string {
+	switch k {
+	case KindNull:
+		return "Null"
+	case KindInt:
+		return "Int"
+	case KindText:
+		return "Text"
+	case KindList:
+		return "List"
+	case KindUUID:
+		return "Uuid"
+	case KindBigInt:
+		return "BigInt"
+	case KindCustom:
+		return "Custom"
+	case KindFloat:
+		return "Float"
+	default:
+		return "Raw"
+	}
+}
+
+// TypedValue is a column value together with the server type it came from.
+// Only the field matching Kind is set.
+type TypedValue struct {
+	Kind   ValueKind
+	Int    int64
+	Float  float64
+	Text   string
+	List   []TypedValue
+	UUID   UUID
+	Big    *big.Int
+	Custom interface{}
+}
+
+// TypedRow is a row whose values keep their server type.
+type TypedRow map[string]TypedValue
+
+// Interface returns v in the form used by Row: nil, int64, string,
+// float64, []interface{}, the canonical string form of a UUID, *big.Int, the result
+// of a registered parser, or the raw source string for unrecognized values.
+func (v TypedValue) Interface() interface{} {
+	switch v.Kind {
+	case KindNull:
+		return nil
+	case KindInt:
+		return v.Int
+	case KindFloat:
+		return v.Float
+	case KindList:
+		list := make([]interface{}, len(v.List))
+		for i, elem := range v.List {
+			list[i] = elem.Interface()
+		}
+		return list
+	case KindUUID:
+		return v.UUID.String()
+	case KindBigInt:
+		return v.Big
+	case KindCustom:
+		return v.Custom
+	default:
+		return v.Text
+	}
+}
+
+func parseValue(value string) interface{} {
+	return parseTypedValue(value).Interface()
+}
+
+func parseTypedValue(value string) TypedValue {
+	value = strings.TrimSpace(value)
+
+	if value == "Null" {
+		return TypedValue{Kind: KindNull}
+	}
+
+	if strings.HasPrefix(value, "Int(") && strings.HasSuffix(value, ")") {
+		numStr := stripDigitSeparators(value[4 : len(value)-1])
+		if num, err := strconv.ParseInt(numStr, 10, 64); err == nil {
+			return TypedValue{Kind: KindInt, Int: num}
+		}
+		if n, ok := new(big.Int).SetString(numStr, 10); ok {
+			return TypedValue{Kind: KindBigInt, Big: n}
+		}
+	}
+
+	if strings.HasPrefix(value, "BigInt(") && strings.HasSuffix(value, ")") {
+		if n, ok := new(big.Int).SetString(stripDigitSeparators(value[7:len(value)-1]), 10); ok {
+			return TypedValue{Kind: KindBigInt, Big: n}
+		}
+	}
+
+	if strings.HasPrefix(value, "Float(") && strings.HasSuffix(value, ")") {
+		if f, err := strconv.ParseFloat(stripDigitSeparators(value[6:len(value)-1]), 64); err == nil {
+			return TypedValue{Kind: KindFloat, Float: f}
+		}
+	}
+
+	if strings.HasPrefix(value, "Text(") && strings.HasSuffix(value, ")") {
+		text := value[5 : len(value)-1]
+		return TypedValue{Kind: KindText, Text: strings.Trim(text, "\"")}
+	}
+
+	if strings.HasPrefix(value, "Uuid(") && strings.HasSuffix(value, ")") {
+		if u, err := ParseUUID(strings.Trim(value[5:len(value)-1], "\"")); err == nil {
+			return TypedValue{Kind: KindUUID, UUID: u}
+		}
+	}
+
+	if strings.HasPrefix(value, "List([") && strings.HasSuffix(value, "])") {
+		inner := strings.TrimSpace(value[6 : len(value)-2])
+		list := TypedValue{Kind: KindList, List: []TypedValue{}}
+		if inner == "" {
+			return list
+		}
+		for _, elem := range splitTopLevel(inner) {
+			list.List = append(list.List, parseTypedValue(elem))
+		}
+		return list
+	}
+
+	if v, ok := parseCustomValue(value); ok {
+		return v
+	}
+
+	return TypedValue{Kind: KindRaw, Text: value}
+}
+
+// stripDigitSeparators removes the underscores the server may group digits
+// with, as in 1_000_000. strconv only accepts them with a base prefix.
+func stripDigitSeparators(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+}
+
+var (
+	valueParsersMu sync.RWMutex
+	valueParsers   = map[string]func(inner string) (interface{}, error){}
+)
+
+// RegisterValueParser adds a decoder for values of the form prefix(...),
+// for server types the SDK does not know, such as Money(...). fn receives
+// the text between the parentheses, and a value it fails to decode is kept
+// as its raw string. Registering a nil fn removes the parser. Parsers apply
+// to every client. It panics if prefix names a built-in type.
+func RegisterValueParser(prefix string, fn func(inner string) (interface{}, error)) {
+	prefix = strings.TrimSuffix(prefix, "(")
+	switch prefix {
+	case "Int", "BigInt", "Float", "Text", "List", "Uuid":
+		panic("poubelle: cannot register a parser for built-in type " + prefix)
+	}
+
+	valueParsersMu.Lock()
+	defer valueParsersMu.Unlock()
+	if fn == nil {
+		delete(valueParsers, prefix)
+		return
+	}
+	valueParsers[prefix] = fn
+}
+
+func parseCustomValue(value string) (TypedValue, bool) {
+	prefix, inner, ok := strings.Cut(value, "(")
+	if !ok || !strings.HasSuffix(inner, ")") {
+		return TypedValue{}, false
+	}
+
+	valueParsersMu.RLock()
+	fn := valueParsers[prefix]
+	valueParsersMu.RUnlock()
+	if fn == nil {
+		return TypedValue{}, false
+	}
+
+	v, err := fn(inner[:len(inner)-1])
+	if err != nil {
+		return TypedValue{}, false
+	}
+	return TypedValue{Kind: KindCustom, Custom: v}, true
+}
+
+// splitTopLevel splits a comma separated sequence of debug-format values,
+// ignoring commas inside quoted strings or nested brackets.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	inQuote := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case inQuote && ch == '\\':
+			i++
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			depth--
+		case ch == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
@@ -0,0 +1,148 @@
+package poubelle
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseTypedValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		kind ValueKind
+	}{
+		{`Int(42)`, KindInt},
+		{`Text("42")`, KindText},
+		{`Null`, KindNull},
+		{`List([Int(1)])`, KindList},
+		{`Int(not-a-number)`, KindRaw},
+		{`Money(1.50)`, KindRaw},
+	}
+
+	for _, tt := range tests {
+		if got := parseTypedValue(tt.in); got.Kind != tt.kind {
+			t.Errorf("parseTypedValue(%s).Kind = %v, want %v", tt.in, got.Kind, tt.kind)
+		}
+	}
+
+	if v := parseTypedValue(`Money(1.50)`); v.Text != "Money(1.50)" {
+		t.Errorf("raw value lost its source text: %q", v.Text)
+	}
+}
+
+func TestParseValueNumberForms(t *testing.T) {
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{`Int(1_000_000)`, int64(1000000)},
+		{`Int(-42)`, int64(-42)},
+		{`Float(1.5e3)`, 1500.0},
+		{`Float(-2.5E-2)`, -0.025},
+		{`Float(1_234.5)`, 1234.5},
+		{`Float(nope)`, "Float(nope)"},
+	}
+
+	for _, tt := range tests {
+		if got := parseValue(tt.in); got != tt.want {
+			t.Errorf("parseValue(%s) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+
+	if n, ok := parseValue(`BigInt(9_223_372_036_854_775_808)`).(interface{ String() string }); !ok || n.String() != "9223372036854775808" {
+		t.Errorf("BigInt with separators = %v", n)
+	}
+}
+
+func TestExecuteTyped(t *testing.T) {
+	client := connectMock(t, func(query string) string {
+		return "{\"id\": Int(1), \"code\": Text(\"1\"), \"note\": Null}\n"
+	})
+
+	rows, err := client.ExecuteTyped("SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+
+	row := rows[0]
+	if row["id"].Kind != KindInt || row["id"].Int != 1 {
+		t.Errorf("id = %+v", row["id"])
+	}
+	if row["code"].Kind != KindText || row["code"].Text != "1" {
+		t.Errorf("code = %+v, want Text even though it looks numeric", row["code"])
+	}
+	if row["note"].Kind != KindNull {
+		t.Errorf("note = %+v", row["note"])
+	}
+}
+
+func TestParseValueList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []interface{}
+	}{
+		{`List([Int(1), Int(2), Text("a")])`, []interface{}{int64(1), int64(2), "a"}},
+		{`List([])`, []interface{}{}},
+		{`List([Text("a, b"), Null])`, []interface{}{"a, b", nil}},
+		{`List([List([Int(1)]), List([])])`, []interface{}{[]interface{}{int64(1)}, []interface{}{}}},
+	}
+
+	for _, tt := range tests {
+		got := parseValue(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValue(%s) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+type money struct {
+	Cents int64
+}
+
+func TestRegisterValueParser(t *testing.T) {
+	RegisterValueParser("Money", func(inner string) (interface{}, error) {
+		whole, frac, ok := strings.Cut(inner, ".")
+		if !ok || len(frac) != 2 {
+			return nil, fmt.Errorf("bad money %q", inner)
+		}
+		w, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseInt(frac, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return money{Cents: w*100 + f}, nil
+	})
+	t.Cleanup(func() { RegisterValueParser("Money", nil) })
+
+	if got := parseValue("Money(12.50)"); got != (money{Cents: 1250}) {
+		t.Errorf("parseValue(Money(12.50)) = %#v", got)
+	}
+	if v := parseTypedValue("Money(12.50)"); v.Kind != KindCustom {
+		t.Errorf("Kind = %v, want Custom", v.Kind)
+	}
+	if got := parseValue("Money(lots)"); got != "Money(lots)" {
+		t.Errorf("failed decode = %#v, want raw string", got)
+	}
+
+	rows := parseRows(`{"price": Money(3.99), "qty": Int(2)}`)
+	if want := []Row{{"price": money{Cents: 399}, "qty": int64(2)}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("parseRows() = %#v, want %#v", rows, want)
+	}
+}
+
+func TestRegisterValueParserBuiltin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a parser for Int did not panic")
+		}
+	}()
+	RegisterValueParser("Int", func(inner string) (interface{}, error) { return "custom", nil })
+}
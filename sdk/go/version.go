@@ -0,0 +1,54 @@
+package poubelle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// The range of protocol versions this SDK speaks. Servers that announce no
+// version speak protocol 1.
+const (
+	minProtocolVersion = 1
+	maxProtocolVersion = 1
+)
+
+// ErrUnsupportedServerVersion is returned by Connect when the server
+// announces a protocol version outside the range this SDK supports.
+var ErrUnsupportedServerVersion = errors.New("unsupported server protocol version")
+
+// protocolVersionRe finds the version in a banner such as
+// "Connected to Poubelle DB (protocol 2)".
+var protocolVersionRe = regexp.MustCompile(`(?i)\bprotocol[ =:]*v?(\d+)`)
+
+func parseProtocolVersion(banner string) int {
+	m := protocolVersionRe.FindStringSubmatch(banner)
+	if m == nil {
+		return minProtocolVersion
+	}
+	v, err := strconv.Atoi(m[1])
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+func checkProtocolVersion(v int) error {
+	if v < minProtocolVersion || v > maxProtocolVersion {
+		return fmt.Errorf("%w: server speaks protocol %d, this SDK supports %d to %d",
+			ErrUnsupportedServerVersion, v, minProtocolVersion, maxProtocolVersion)
+	}
+	return nil
+}
+
+// ProtocolVersion returns the protocol version of the current connection,
+// or 0 if the client is not connected.
+func (c *Client) ProtocolVersion() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return 0
+	}
+	return c.protocolVersion
+}
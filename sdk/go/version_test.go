@@ -0,0 +1,74 @@
+package poubelle
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func bannerHandshake(banner string) func(net.Conn, *bufio.Reader) bool {
+	return func(conn net.Conn, reader *bufio.Reader) bool {
+		fmt.Fprint(conn, "Username: ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		fmt.Fprint(conn, "Password: ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return false
+		}
+		fmt.Fprint(conn, banner+"\n")
+		return true
+	}
+}
+
+func TestProtocolVersion(t *testing.T) {
+	tests := []struct {
+		banner string
+		want   int
+		err    bool
+	}{
+		{"Connected to Poubelle DB", 1, false},
+		{"Connected to Poubelle DB (protocol 1)", 1, false},
+		{"Connected to Poubelle DB (protocol 2)", 0, true},
+		{"Connected to Poubelle DB protocol=0", 0, true},
+	}
+
+	for _, tt := range tests {
+		s := &mockServer{
+			handle:    func(string) string { return "ok\n" },
+			handshake: bannerHandshake(tt.banner),
+		}
+		s.start(t)
+
+		client, err := NewClient(s.dsn())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.Connect()
+		if tt.err {
+			if !errors.Is(err, ErrUnsupportedServerVersion) {
+				t.Errorf("%q: Connect() = %v, want ErrUnsupportedServerVersion", tt.banner, err)
+			} else if !strings.Contains(err.Error(), "supports 1 to 1") {
+				t.Errorf("%q: error does not name the supported range: %v", tt.banner, err)
+			}
+			if _, err := client.Query("SELECT 1"); !errors.Is(err, ErrNotConnected) {
+				t.Errorf("%q: query after mismatch: err = %v, want ErrNotConnected", tt.banner, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: Connect() = %v", tt.banner, err)
+			continue
+		}
+		if got := client.ProtocolVersion(); got != tt.want {
+			t.Errorf("%q: ProtocolVersion() = %d, want %d", tt.banner, got, tt.want)
+		}
+		if got, err := client.Query("SELECT 1"); err != nil || got != "ok" {
+			t.Errorf("%q: Query() = %q, %v", tt.banner, got, err)
+		}
+		client.Close()
+	}
+}